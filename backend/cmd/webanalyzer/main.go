@@ -7,13 +7,18 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 	"webanalyzer/internal/api/v1/router"
 	"webanalyzer/internal/cache"
 	"webanalyzer/internal/config"
 	"webanalyzer/internal/debug"
+	"webanalyzer/internal/fetcher"
+	"webanalyzer/internal/jobs"
 	"webanalyzer/internal/log"
+	"webanalyzer/internal/outbound"
+	"webanalyzer/internal/service"
 )
 
 func init() {
@@ -24,6 +29,10 @@ func init() {
 func main() {
 	defer log.Sync()
 	cache.Init()
+	service.SetAllowedRequestHeaders(strings.Split(config.AppConfig.AllowedRequestHeaders, ","))
+	pageFetcher := configureFetcher()
+	service.SetFetchLimits(service.FetchLimits{MaxBodyBytes: config.AppConfig.MaxBodyBytes, AllowNonHTML: config.AppConfig.AllowNonHTML})
+	jobs.Init(config.AppConfig.JobWorkers, config.AppConfig.JobQueueSize, time.Duration(config.AppConfig.JobTTLMinutes)*time.Minute)
 
 	r := router.New()
 
@@ -75,5 +84,48 @@ func main() {
 	if err := server.Shutdown(ctx); err != nil {
 		log.Logger.Fatal("Server forced to shutdown", zap.Error(err))
 	}
+	jobs.Queue.Stop()
+	if err := pageFetcher.Close(); err != nil {
+		log.Logger.Error("Failed to close the fetcher", zap.Error(err))
+	}
 	log.Logger.Info("Server exited successfully")
 }
+
+// sends the requests to analyzed sites through the configured fetcher, live ones and recorders
+// go through the proxy, CA bundle, host overrides and private network guard
+func configureFetcher() fetcher.Fetcher {
+	overrides, err := outbound.ParseHostOverrides(config.AppConfig.HostOverrides)
+	if err != nil {
+		log.Logger.Fatal("Invalid host overrides", zap.Error(err))
+	}
+	allow, err := outbound.ParseAllowList(config.AppConfig.PrivateNetworkAllow)
+	if err != nil {
+		log.Logger.Fatal("Invalid private network allow-list", zap.Error(err))
+	}
+
+	settings := outbound.Settings{
+		ProxyURL:             config.AppConfig.ProxyURL,
+		NoProxy:              config.AppConfig.NoProxy,
+		CABundlePath:         config.AppConfig.CABundlePath,
+		HostOverrides:        overrides,
+		BlockPrivateNetworks: config.AppConfig.BlockPrivateNetworks,
+		AllowList:            allow,
+	}
+	transport, err := outbound.NewTransport(settings)
+	if err != nil {
+		log.Logger.Fatal("Failed to configure the outbound transport", zap.Error(err))
+	}
+
+	pageFetcher, err := fetcher.New(fetcher.Settings{
+		Mode:         config.AppConfig.FetchMode,
+		Archive:      config.AppConfig.FetchArchive,
+		MaxBodyBytes: config.AppConfig.MaxBodyBytes,
+	}, transport)
+	if err != nil {
+		log.Logger.Fatal("Failed to configure the fetcher", zap.Error(err))
+	}
+	log.Logger.Info("Fetcher configured", zap.String("mode", config.AppConfig.FetchMode), zap.String("archive", config.AppConfig.FetchArchive))
+
+	service.SetFetcher(pageFetcher)
+	return pageFetcher
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+	"webanalyzer/internal/log"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/service"
+	"webanalyzer/internal/util/analyzer"
+	"webanalyzer/pkg/response"
+)
+
+const ndjsonContentType = "application/x-ndjson"
+
+// AnalyzeBatchHandler analyzes a list of URLs in one call.
+// results are returned together, or streamed one JSON object per line when the client accepts application/x-ndjson
+func AnalyzeBatchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req model.BatchAnalyzeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if len(req.URLs) == 0 {
+		response.Error(w, http.StatusBadRequest, "missing 'urls' field")
+		return
+	}
+
+	if len(req.URLs) > analyzer.MaxBatchURLs {
+		response.Error(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("at most %d urls are allowed per batch", analyzer.MaxBatchURLs))
+		return
+	}
+
+	if err := service.ValidateAnalyzeOptions(req.Options); err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !strings.Contains(r.Header.Get("Accept"), ndjsonContentType) {
+		results := service.AnalyzeBatch(r.Context(), req.URLs, req.Options, nil)
+		response.Success(w, results, "")
+		return
+	}
+
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	rc := http.NewResponseController(w)
+	service.AnalyzeBatch(r.Context(), req.URLs, req.Options, func(result model.BatchResult) {
+		if err := encoder.Encode(result); err != nil {
+			log.Logger.Warn("failed to write batch result", zap.String("url", result.URL), zap.Error(err))
+			return
+		}
+		_ = rc.Flush()
+	})
+}
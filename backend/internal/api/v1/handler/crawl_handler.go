@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/service"
+	"webanalyzer/internal/util"
+	"webanalyzer/pkg/response"
+)
+
+// CrawlHandler crawls the internal pages of a site starting from the given URL and returns the site report
+func CrawlHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req model.CrawlRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.URL == "" {
+		response.Error(w, http.StatusBadRequest, "missing 'url' field")
+		return
+	}
+
+	if !util.IsValidURL(req.URL) {
+		response.Error(w, http.StatusBadRequest, "invalid 'url' format")
+		return
+	}
+
+	if err := service.ValidateAnalyzeOptions(req.Options); err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := service.Crawl(r.Context(), req.URL, req.MaxDepth, req.MaxPages, req.Options)
+	if err != nil {
+		response.ErrorWithCode(w, analyzeErrorStatus(err), analyzeErrorCode(err), fmt.Sprintf("failed to crawl site: %v", err))
+		return
+	}
+
+	response.Success(w, report, "")
+}
@@ -2,15 +2,19 @@ package handler
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 	"webanalyzer/internal/cache"
 	"webanalyzer/internal/log"
+	"webanalyzer/internal/model"
 	"webanalyzer/internal/service"
 	"webanalyzer/internal/util"
 	"webanalyzer/pkg/response"
@@ -39,30 +43,23 @@ func AnalyzePageHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if cached, found := cache.Store.Get(url); found {
+	opts, err := analyzeOptionsFromQuery(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cacheKey := analysisCacheKey(url, opts)
+	if cached, found := cache.Store.Get(cacheKey); found {
 		log.Logger.Info("Cache hit:", zap.String("url", url))
 		w.Header().Set("Content-Type", "application/json")
 		response.Success(w, cached, "")
 		return
 	}
 
-	result, err := service.AnalyzePage(url)
+	result, err := service.AnalyzePage(r.Context(), url, opts)
 	if err != nil {
-		var statusCode int
-		switch {
-		case errors.Is(err, context.DeadlineExceeded):
-			statusCode = http.StatusGatewayTimeout
-		case strings.Contains(err.Error(), "connection refused"),
-			strings.Contains(err.Error(), "no such host"),
-			strings.Contains(err.Error(), "unexpected status code: 403"),
-			strings.Contains(err.Error(), "timeout"):
-			statusCode = http.StatusBadGateway
-		case strings.Contains(err.Error(), "service unavailable"):
-			statusCode = http.StatusServiceUnavailable
-		default:
-			statusCode = http.StatusInternalServerError
-		}
-		response.Error(w, statusCode, fmt.Sprintf("failed to analyze page: %v", err))
+		response.ErrorWithCode(w, analyzeErrorStatus(err), analyzeErrorCode(err), fmt.Sprintf("failed to analyze page: %v", err))
 		return
 	}
 
@@ -70,11 +67,118 @@ func AnalyzePageHandler(w http.ResponseWriter, r *http.Request) {
 		response.Error(w, http.StatusInternalServerError, "failed to analyze page")
 		return
 	}
-	cache.Store.Set(url, result, 1*time.Hour)
+	cache.Store.Set(cacheKey, result, 1*time.Hour)
 	w.Header().Set("Content-Type", "application/json")
 	response.Success(w, result, "")
 }
 
+// maps an analysis error to the HTTP status code returned to the client
+func analyzeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidRequestOptions):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrRobotsDisallowed), errors.Is(err, service.ErrBlockedDestination):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrNoSitemap):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrUnsupportedContentType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, service.ErrUnsupportedContentEncoding), errors.Is(err, service.ErrTLSVerification):
+		return http.StatusBadGateway
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, service.ErrRedirectLoop), errors.Is(err, service.ErrTooManyRedirects):
+		return http.StatusBadGateway
+	case strings.Contains(err.Error(), "connection refused"),
+		strings.Contains(err.Error(), "no such host"),
+		strings.Contains(err.Error(), "unexpected status code: 403"),
+		strings.Contains(err.Error(), "timeout"):
+		return http.StatusBadGateway
+	case strings.Contains(err.Error(), "service unavailable"):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// error codes telling a blocked destination apart from the other 403 responses and a rejected certificate from the other 502 ones
+const (
+	errorCodeBlockedDestination = "blocked_destination"
+	errorCodeTLSVerification    = "tls_verification_failed"
+)
+
+// the machine readable code returned with an analysis error, empty when the status code says enough
+func analyzeErrorCode(err error) string {
+	switch {
+	case errors.Is(err, service.ErrBlockedDestination):
+		return errorCodeBlockedDestination
+	case errors.Is(err, service.ErrTLSVerification):
+		return errorCodeTLSVerification
+	default:
+		return ""
+	}
+}
+
+// reads the analysis options from the query string.
+// custom headers are passed as header=Name:Value and cookies as cookie=name=value, both can be repeated
+func analyzeOptionsFromQuery(r *http.Request) (model.AnalyzeOptions, error) {
+	query := r.URL.Query()
+	opts := model.AnalyzeOptions{
+		IncludeLinks:   query.Get("include_links") == "true",
+		IgnoreRobots:   query.Get("ignore_robots") == "true",
+		CheckResources: query.Get("check_resources") == "true",
+		UserAgent:      query.Get("user_agent"),
+	}
+
+	for _, header := range query["header"] {
+		name, value, found := strings.Cut(header, ":")
+		if !found {
+			return opts, fmt.Errorf("invalid 'header' parameter, expected Name:Value")
+		}
+		if opts.Headers == nil {
+			opts.Headers = model.RequestValues{}
+		}
+		opts.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	for _, cookie := range query["cookie"] {
+		name, value, found := strings.Cut(cookie, "=")
+		if !found {
+			return opts, fmt.Errorf("invalid 'cookie' parameter, expected name=value")
+		}
+		if opts.Cookies == nil {
+			opts.Cookies = model.RequestValues{}
+		}
+		opts.Cookies[strings.TrimSpace(name)] = value
+	}
+
+	return opts, service.ValidateAnalyzeOptions(opts)
+}
+
+// builds the cache key for an analysis, so results with different options are cached separately.
+// the custom request options are hashed so credentials are not kept in the key
+func analysisCacheKey(url string, opts model.AnalyzeOptions) string {
+	return fmt.Sprintf("%s|links=%t|robots=%t|resources=%t|request=%s", url, opts.IncludeLinks, !opts.IgnoreRobots, opts.CheckResources, requestOptionsDigest(opts))
+}
+
+// hashes the user agent, headers and cookies, empty when none are set
+func requestOptionsDigest(opts model.AnalyzeOptions) string {
+	if opts.UserAgent == "" && len(opts.Headers) == 0 && len(opts.Cookies) == 0 {
+		return ""
+	}
+
+	parts := []string{"ua=" + opts.UserAgent}
+	for name, value := range opts.Headers {
+		parts = append(parts, "h="+http.CanonicalHeaderKey(name)+":"+value)
+	}
+	for name, value := range opts.Cookies {
+		parts = append(parts, "c="+name+"="+value)
+	}
+	sort.Strings(parts)
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
 func MetricsHandler() http.Handler {
 	return promhttp.Handler()
 }
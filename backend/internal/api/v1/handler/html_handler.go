@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"webanalyzer/internal/service"
+	"webanalyzer/internal/util"
+	"webanalyzer/pkg/response"
+)
+
+var errMissingUpload = errors.New("missing 'file' field")
+
+// AnalyzeHTMLHandler analyzes HTML posted by the client, either as the request body or as the 'file' field of a multipart form.
+// base_url resolves the relative links, check_links=true checks the links it finds, the other analysis options are the ones of /analyze
+func AnalyzeHTMLHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	doc := service.HTMLDocument{
+		BaseURL:    query.Get("base_url"),
+		CheckLinks: query.Get("check_links") == "true",
+	}
+	if doc.BaseURL != "" && !util.IsValidURL(doc.BaseURL) {
+		response.Error(w, http.StatusBadRequest, "invalid 'base_url' format")
+		return
+	}
+
+	opts, err := analyzeOptionsFromQuery(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	maxBytes := service.MaxBodyBytes()
+	doc.HTML, doc.ContentType, err = readHTMLDocument(r, maxBytes)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if int64(len(doc.HTML)) > maxBytes {
+		response.Error(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("HTML document exceeds %d bytes", maxBytes))
+		return
+	}
+
+	result, err := service.AnalyzeHTML(r.Context(), doc, opts)
+	if errors.Is(err, service.ErrEmptyDocument) {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		response.ErrorWithCode(w, analyzeErrorStatus(err), analyzeErrorCode(err), fmt.Sprintf("failed to analyze HTML: %v", err))
+		return
+	}
+
+	response.Success(w, result, "")
+}
+
+// reads up to maxBytes+1 bytes of the posted document and its content type, so callers can tell an oversized one apart
+func readHTMLDocument(r *http.Request, maxBytes int64) ([]byte, string, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBytes+1))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read request body: %w", err)
+		}
+		return body, r.Header.Get("Content-Type"), nil
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid multipart form: %w", err)
+	}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, "", errMissingUpload
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid multipart form: %w", err)
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+		body, err := io.ReadAll(io.LimitReader(part, maxBytes+1))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read uploaded file: %w", err)
+		}
+		return body, part.Header.Get("Content-Type"), nil
+	}
+}
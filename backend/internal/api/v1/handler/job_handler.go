@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"webanalyzer/internal/jobs"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/service"
+	"webanalyzer/internal/util"
+	"webanalyzer/pkg/response"
+)
+
+// CreateJobHandler enqueues an asynchronous page analysis and returns the created job
+func CreateJobHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req model.CreateJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.URL == "" {
+		response.Error(w, http.StatusBadRequest, "missing 'url' field")
+		return
+	}
+
+	if !util.IsValidURL(req.URL) {
+		response.Error(w, http.StatusBadRequest, "invalid 'url' format")
+		return
+	}
+
+	if err := service.ValidateAnalyzeOptions(req.Options); err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	job, err := jobs.Queue.Submit(req.URL, req.Options)
+	if err != nil {
+		response.Error(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	w.Header().Set("Location", r.URL.Path+"/"+job.ID)
+	response.JSON(w, http.StatusAccepted, job, "")
+}
+
+// JobHandler reports the state of a job on GET and cancels it on DELETE
+func JobHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var (
+		job model.AnalysisJob
+		err error
+	)
+	switch r.Method {
+	case http.MethodGet:
+		job, err = jobs.Queue.Get(id)
+	case http.MethodDelete:
+		job, err = jobs.Queue.Cancel(id)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if errors.Is(err, jobs.ErrJobNotFound) {
+		response.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	response.Success(w, job, "")
+}
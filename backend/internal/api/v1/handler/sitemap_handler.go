@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/service"
+	"webanalyzer/internal/util"
+	"webanalyzer/pkg/response"
+)
+
+// SitemapAuditHandler analyzes every URL listed in the sitemaps of a site and returns the sitemap report
+func SitemapAuditHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req model.SitemapAuditRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.URL == "" {
+		response.Error(w, http.StatusBadRequest, "missing 'url' field")
+		return
+	}
+
+	if !util.IsValidURL(req.URL) {
+		response.Error(w, http.StatusBadRequest, "invalid 'url' format")
+		return
+	}
+
+	if req.SitemapURL != "" && !util.IsValidURL(req.SitemapURL) {
+		response.Error(w, http.StatusBadRequest, "invalid 'sitemap_url' format")
+		return
+	}
+
+	if err := service.ValidateAnalyzeOptions(req.Options); err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := service.AuditSitemap(r.Context(), req.URL, req.SitemapURL, req.MaxURLs, req.Options)
+	if err != nil {
+		response.ErrorWithCode(w, analyzeErrorStatus(err), analyzeErrorCode(err), fmt.Sprintf("failed to audit sitemap: %v", err))
+		return
+	}
+
+	response.Success(w, report, "")
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+	"webanalyzer/internal/cache"
+	"webanalyzer/internal/log"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/service"
+	"webanalyzer/internal/util"
+	"webanalyzer/pkg/response"
+)
+
+// AnalyzePageStreamHandler analyzes a page and streams the analysis events to the client as Server-Sent Events
+func AnalyzePageStreamHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		response.Error(w, http.StatusBadRequest, "missing 'url' query parameter")
+		return
+	}
+
+	if !util.IsValidURL(url) {
+		response.Error(w, http.StatusBadRequest, "invalid 'url' format")
+		return
+	}
+
+	opts, err := analyzeOptionsFromQuery(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := response.StartEventStream(w); err != nil {
+		response.Error(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	ctx := r.Context()
+	events := make(chan model.AnalysisEvent, 64)
+	go func() {
+		defer close(events)
+		result, err := service.AnalyzePageWithEvents(ctx, url, opts, func(event model.AnalysisEvent) {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+			}
+		})
+		if err == nil {
+			cache.Store.Set(analysisCacheKey(url, opts), result, 1*time.Hour)
+		}
+	}()
+
+	id := 0
+	for event := range events {
+		id++
+		if err := response.Event(w, id, string(event.Type), event); err != nil {
+			log.Logger.Warn("failed to write analysis event", zap.String("url", url), zap.Error(err))
+			// drain the remaining events so the analysis goroutine can finish
+			for range events {
+			}
+			return
+		}
+	}
+}
@@ -36,3 +36,11 @@ func Compression(next http.Handler) http.Handler {
 func (gzw gzipResponseWriter) Write(b []byte) (int, error) {
 	return gzw.Writer.Write(b)
 }
+
+// Flush pushes the compressed bytes written so far to the client, needed for streaming responses
+func (gzw gzipResponseWriter) Flush() {
+	if err := gzw.Writer.Flush(); err != nil {
+		return
+	}
+	_ = http.NewResponseController(gzw.ResponseWriter).Flush()
+}
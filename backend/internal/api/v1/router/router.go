@@ -20,6 +20,13 @@ func New() http.Handler {
 
 	register("/health", handler.HealthCheckHandler)
 	register("/analyze", handler.AnalyzePageHandler)
+	register("/analyze/stream", handler.AnalyzePageStreamHandler)
+	register("/analyze/html", handler.AnalyzeHTMLHandler)
+	register("/analyze/batch", handler.AnalyzeBatchHandler)
+	register("/crawl", handler.CrawlHandler)
+	register("/sitemap/audit", handler.SitemapAuditHandler)
+	register("/jobs", handler.CreateJobHandler)
+	register("/jobs/{id}", handler.JobHandler)
 
 	return middleware.RecoverPanic(
 		log.Logger,
@@ -3,16 +3,32 @@ package config
 import (
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
+	"strings"
 	"webanalyzer/internal/log"
+	"webanalyzer/internal/util/analyzer"
 )
 
 type Config struct {
-	BasicAuthUser        string `mapstructure:"BASIC_AUTH_USER"`
-	BasicAuthPass        string `mapstructure:"BASIC_AUTH_PASS"`
-	IsDev                string `mapstructure:"IS_DEV"`
-	PublicWebServerPort  string `mapstructure:"PUBLIC_WEB_SERVER_PORT"`
-	MetricsWebServerPort string `mapstructure:"METRICS_WEB_SERVER_PORT"`
-	PprofWebServerPort   string `mapstructure:"PPROF_WEB_SERVER_PORT"`
+	BasicAuthUser         string `mapstructure:"BASIC_AUTH_USER"`
+	BasicAuthPass         string `mapstructure:"BASIC_AUTH_PASS"`
+	IsDev                 string `mapstructure:"IS_DEV"`
+	PublicWebServerPort   string `mapstructure:"PUBLIC_WEB_SERVER_PORT"`
+	MetricsWebServerPort  string `mapstructure:"METRICS_WEB_SERVER_PORT"`
+	PprofWebServerPort    string `mapstructure:"PPROF_WEB_SERVER_PORT"`
+	JobWorkers            int    `mapstructure:"JOB_WORKERS"`
+	JobQueueSize          int    `mapstructure:"JOB_QUEUE_SIZE"`
+	JobTTLMinutes         int    `mapstructure:"JOB_TTL_MINUTES"`
+	AllowedRequestHeaders string `mapstructure:"ALLOWED_REQUEST_HEADERS"`
+	ProxyURL              string `mapstructure:"PROXY_URL"`
+	NoProxy               string `mapstructure:"NO_PROXY"`
+	CABundlePath          string `mapstructure:"CA_BUNDLE_PATH"`
+	HostOverrides         string `mapstructure:"HOST_OVERRIDES"`
+	BlockPrivateNetworks  bool   `mapstructure:"BLOCK_PRIVATE_NETWORKS"`
+	PrivateNetworkAllow   string `mapstructure:"PRIVATE_NETWORK_ALLOW"`
+	MaxBodyBytes          int64  `mapstructure:"MAX_BODY_BYTES"`
+	AllowNonHTML          bool   `mapstructure:"ALLOW_NON_HTML"`
+	FetchMode             string `mapstructure:"FETCH_MODE"`
+	FetchArchive          string `mapstructure:"FETCH_ARCHIVE"`
 }
 
 var AppConfig *Config
@@ -35,6 +51,20 @@ func LoadEnv() {
 	v.SetDefault(PPROF_WEB_SERVER_PORT, "6061")
 	v.SetDefault(METRICS_WEB_SERVER_PORT, "8081")
 	v.SetDefault(IS_DEV, "false")
+	v.SetDefault(JOB_WORKERS, 4)
+	v.SetDefault(JOB_QUEUE_SIZE, 100)
+	v.SetDefault(JOB_TTL_MINUTES, 60)
+	v.SetDefault(ALLOWED_REQUEST_HEADERS, strings.Join(analyzer.DefaultAllowedRequestHeaders, ","))
+	v.SetDefault(PROXY_URL, "")
+	v.SetDefault(NO_PROXY, "")
+	v.SetDefault(CA_BUNDLE_PATH, "")
+	v.SetDefault(HOST_OVERRIDES, "")
+	v.SetDefault(BLOCK_PRIVATE_NETWORKS, true)
+	v.SetDefault(PRIVATE_NETWORK_ALLOW, "")
+	v.SetDefault(MAX_BODY_BYTES, analyzer.DefaultMaxBodyBytes)
+	v.SetDefault(ALLOW_NON_HTML, false)
+	v.SetDefault(FETCH_MODE, "live")
+	v.SetDefault(FETCH_ARCHIVE, "")
 
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
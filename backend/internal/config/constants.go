@@ -7,4 +7,18 @@ const (
 	PUBLIC_WEB_SERVER_PORT  = "PUBLIC_WEB_SERVER_PORT"
 	METRICS_WEB_SERVER_PORT = "METRICS_WEB_SERVER_PORT"
 	PPROF_WEB_SERVER_PORT   = "PPROF_WEB_SERVER_PORT"
+	JOB_WORKERS             = "JOB_WORKERS"
+	JOB_QUEUE_SIZE          = "JOB_QUEUE_SIZE"
+	JOB_TTL_MINUTES         = "JOB_TTL_MINUTES"
+	ALLOWED_REQUEST_HEADERS = "ALLOWED_REQUEST_HEADERS"
+	PROXY_URL               = "PROXY_URL"
+	NO_PROXY                = "NO_PROXY"
+	CA_BUNDLE_PATH          = "CA_BUNDLE_PATH"
+	HOST_OVERRIDES          = "HOST_OVERRIDES"
+	BLOCK_PRIVATE_NETWORKS  = "BLOCK_PRIVATE_NETWORKS"
+	PRIVATE_NETWORK_ALLOW   = "PRIVATE_NETWORK_ALLOW"
+	MAX_BODY_BYTES          = "MAX_BODY_BYTES"
+	ALLOW_NON_HTML          = "ALLOW_NON_HTML"
+	FETCH_MODE              = "FETCH_MODE"
+	FETCH_ARCHIVE           = "FETCH_ARCHIVE"
 )
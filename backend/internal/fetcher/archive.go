@@ -0,0 +1,303 @@
+package fetcher
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"webanalyzer/internal/model"
+)
+
+// Exchange is a recorded request and the response it got
+type Exchange struct {
+	Method     string        `json:"method"`
+	URL        string        `json:"url"`
+	StatusCode int           `json:"status_code"`
+	Header     http.Header   `json:"header"`
+	Body       []byte        `json:"body"`
+	StartedAt  time.Time     `json:"started_at"`
+	Duration   time.Duration `json:"duration"`
+	// Truncated is set when Body is not the whole response body, it was cut at the size limit or not read to the end
+	Truncated bool `json:"truncated,omitempty"`
+	// RequestHeader is only written to HAR files, credentials are redacted
+	RequestHeader http.Header `json:"-"`
+}
+
+// identifies the recorded exchange replayed for a request
+func exchangeKey(method, rawURL string) string {
+	return method + " " + rawURL
+}
+
+func isHAR(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".har")
+}
+
+// writes ex to its own JSON file in dir
+func writeFixture(dir string, ex Exchange) error {
+	data, err := json.MarshalIndent(ex, "", "  ")
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256([]byte(exchangeKey(ex.Method, ex.URL)))
+	name := fmt.Sprintf("%s-%x.json", strings.ToLower(ex.Method), sum[:12])
+	return os.WriteFile(filepath.Join(dir, name), data, 0o644)
+}
+
+// reads every JSON fixture in dir
+func readFixtures(dir string) ([]Exchange, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	exchanges := make([]Exchange, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var ex Exchange
+		if err := json.Unmarshal(data, &ex); err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", path, err)
+		}
+		exchanges = append(exchanges, ex)
+	}
+	return exchanges, nil
+}
+
+// the subset of HAR 1.2 written and read by the recorder and the replay fetcher
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	Cookies     []harNameValue `json:"cookies"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	Cookies     []harNameValue `json:"cookies"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+	// custom HAR field, set when the stored body is not the whole response body
+	Truncated bool `json:"_truncated,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// written as the HAR creator, HAR files of other tools hold decoded bodies
+const harCreatorName = "webanalyzer"
+
+// request headers written to a HAR file as they were sent, the values of the others may be credentials and are redacted
+var plainRequestHeaders = map[string]bool{
+	"Accept":          true,
+	"Accept-Encoding": true,
+	"Accept-Language": true,
+	"Cache-Control":   true,
+	"Referer":         true,
+	"User-Agent":      true,
+}
+
+// response headers whose values are credentials, the attributes of a Set-Cookie are kept for the cookie audit
+var credentialResponseHeaders = map[string]bool{
+	"Authentication-Info":       true,
+	"Proxy-Authentication-Info": true,
+	"Set-Cookie":                true,
+	"Set-Cookie2":               true,
+	"X-Api-Key":                 true,
+	"X-Auth-Token":              true,
+	"X-Csrf-Token":              true,
+}
+
+// a copy of header with the credentials redacted, cookies keep their name and attributes
+func redactResponseHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	for name, values := range redacted {
+		canonical := http.CanonicalHeaderKey(name)
+		if !credentialResponseHeaders[canonical] {
+			continue
+		}
+		for i, value := range values {
+			if canonical != "Set-Cookie" && canonical != "Set-Cookie2" {
+				values[i] = model.RedactedValue
+				continue
+			}
+			cookie, attributes, _ := strings.Cut(value, ";")
+			cookieName, _, _ := strings.Cut(cookie, "=")
+			values[i] = strings.TrimSpace(cookieName) + "=" + model.RedactedValue
+			if attributes != "" {
+				values[i] += ";" + attributes
+			}
+		}
+	}
+	return redacted
+}
+
+// writes the exchanges to a HAR file at path, bodies are stored base64 encoded as they came off the wire
+func writeHAR(path string, exchanges []Exchange) error {
+	har := harFile{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: harCreatorName, Version: "1.0"},
+		Entries: make([]harEntry, 0, len(exchanges)),
+	}}
+	for _, ex := range exchanges {
+		ms := float64(ex.Duration) / float64(time.Millisecond)
+		har.Log.Entries = append(har.Log.Entries, harEntry{
+			StartedDateTime: ex.StartedAt,
+			Time:            ms,
+			Request: harRequest{
+				Method:      ex.Method,
+				URL:         ex.URL,
+				HTTPVersion: "HTTP/1.1",
+				Headers:     harHeaders(ex.RequestHeader, plainRequestHeaders),
+				QueryString: []harNameValue{},
+				Cookies:     []harNameValue{},
+				HeadersSize: -1,
+				BodySize:    0,
+			},
+			Response: harResponse{
+				Status:      ex.StatusCode,
+				StatusText:  http.StatusText(ex.StatusCode),
+				HTTPVersion: "HTTP/1.1",
+				Headers:     harHeaders(ex.Header, nil),
+				Cookies:     []harNameValue{},
+				Content: harContent{
+					Size:      len(ex.Body),
+					MimeType:  ex.Header.Get("Content-Type"),
+					Text:      base64.StdEncoding.EncodeToString(ex.Body),
+					Encoding:  "base64",
+					Truncated: ex.Truncated,
+				},
+				RedirectURL: ex.Header.Get("Location"),
+				HeadersSize: -1,
+				BodySize:    len(ex.Body),
+			},
+			Timings: harTimings{Wait: ms},
+		})
+	}
+
+	data, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// reads the exchanges of a HAR file, including the ones exported by browsers
+func readHAR(path string) ([]Exchange, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("HAR file %s: %w", path, err)
+	}
+
+	exchanges := make([]Exchange, 0, len(har.Log.Entries))
+	for _, entry := range har.Log.Entries {
+		header := http.Header{}
+		for _, h := range entry.Response.Headers {
+			header.Add(h.Name, h.Value)
+		}
+		body := []byte(entry.Response.Content.Text)
+		if entry.Response.Content.Encoding == "base64" {
+			if body, err = base64.StdEncoding.DecodeString(entry.Response.Content.Text); err != nil {
+				return nil, fmt.Errorf("HAR file %s: body of %s: %w", path, entry.Request.URL, err)
+			}
+		}
+		// browsers store the decoded body next to the original Content-Encoding
+		if har.Log.Creator.Name != harCreatorName {
+			header.Del("Content-Encoding")
+		}
+
+		exchanges = append(exchanges, Exchange{
+			Method:     entry.Request.Method,
+			URL:        entry.Request.URL,
+			StatusCode: entry.Response.Status,
+			Header:     header,
+			Body:       body,
+			Truncated:  entry.Response.Content.Truncated,
+			StartedAt:  entry.StartedDateTime,
+			Duration:   time.Duration(entry.Time * float64(time.Millisecond)),
+		})
+	}
+	return exchanges, nil
+}
+
+// the headers sorted by name, values of headers missing from plain are redacted unless plain is nil
+func harHeaders(header http.Header, plain map[string]bool) []harNameValue {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	headers := []harNameValue{}
+	for _, name := range names {
+		for _, value := range header[name] {
+			if plain != nil && !plain[http.CanonicalHeaderKey(name)] {
+				value = model.RedactedValue
+			}
+			headers = append(headers, harNameValue{Name: name, Value: value})
+		}
+	}
+	return headers
+}
@@ -0,0 +1,80 @@
+package fetcher
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"webanalyzer/internal/util/analyzer"
+)
+
+var (
+	ErrInvalidSettings = errors.New("invalid fetcher settings")
+	ErrNotRecorded     = errors.New("request was not recorded")
+)
+
+// modes selecting the Fetcher built by New
+const (
+	ModeLive   = "live"
+	ModeRecord = "record"
+	ModeReplay = "replay"
+)
+
+// Fetcher sends a single request to an analyzed site, redirects are followed by the http.Client around it
+type Fetcher interface {
+	http.RoundTripper
+	// Close releases the fetcher, a recording fetcher writes out its archive
+	Close() error
+}
+
+// Settings selects the fetcher and the archive it records to or replays from.
+// archives ending in .har are HAR files, any other path is a directory with one JSON fixture per request
+type Settings struct {
+	Mode    string
+	Archive string
+	// MaxBodyBytes caps the response bytes kept per recorded request, longer bodies are stored cut and marked truncated
+	MaxBodyBytes int64
+}
+
+// New builds the fetcher of settings, live and recording fetchers send their requests through transport
+func New(settings Settings, transport http.RoundTripper) (Fetcher, error) {
+	if settings.MaxBodyBytes <= 0 {
+		settings.MaxBodyBytes = analyzer.DefaultMaxBodyBytes
+	}
+
+	switch settings.Mode {
+	case "", ModeLive:
+		return NewLive(transport), nil
+	case ModeRecord:
+		if settings.Archive == "" {
+			return nil, fmt.Errorf("%w: recording needs an archive path", ErrInvalidSettings)
+		}
+		return NewRecorder(NewLive(transport), settings.Archive, settings.MaxBodyBytes)
+	case ModeReplay:
+		if settings.Archive == "" {
+			return nil, fmt.Errorf("%w: replay needs an archive path", ErrInvalidSettings)
+		}
+		return NewReplay(settings.Archive)
+	default:
+		return nil, fmt.Errorf("%w: unknown mode %q", ErrInvalidSettings, settings.Mode)
+	}
+}
+
+// Live sends the requests over the network
+type Live struct {
+	transport http.RoundTripper
+}
+
+func NewLive(transport http.RoundTripper) *Live {
+	return &Live{transport: transport}
+}
+
+func (l *Live) RoundTrip(req *http.Request) (*http.Response, error) {
+	return l.transport.RoundTrip(req)
+}
+
+func (l *Live) Close() error {
+	if closer, ok := l.transport.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+	return nil
+}
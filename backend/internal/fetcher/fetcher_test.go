@@ -0,0 +1,174 @@
+package fetcher
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"webanalyzer/internal/model"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Add("Set-Cookie", "session=1; Secure")
+			fmt.Fprint(w, "<html><title>Recorded</title></html>")
+		case "/moved":
+			http.Redirect(w, r, "/", http.StatusMovedPermanently)
+		case "/large":
+			fmt.Fprint(w, strings.Repeat("x", 100))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		archive string
+	}{
+		{name: "HAR file", archive: filepath.Join(t.TempDir(), "site.har")},
+		{name: "fixture directory", archive: filepath.Join(t.TempDir(), "fixtures")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder, err := New(Settings{Mode: ModeRecord, Archive: tt.archive, MaxBodyBytes: 64}, http.DefaultTransport)
+			if err != nil {
+				t.Fatalf("New() unexpected error: %v", err)
+			}
+			live := fetchAll(t, recorder, server.URL, map[string]string{"Authorization": "Bearer s3cr3t"})
+			if err := recorder.Close(); err != nil {
+				t.Fatalf("Close() unexpected error: %v", err)
+			}
+			paths, _ := filepath.Glob(filepath.Join(tt.archive, "*.json"))
+			for _, path := range append(paths, tt.archive) {
+				if data, err := os.ReadFile(path); err == nil && strings.Contains(string(data), "session=1") {
+					t.Errorf("archive %s contains the cookie value: %s", path, data)
+				}
+			}
+
+			replay, err := New(Settings{Mode: ModeReplay, Archive: tt.archive}, nil)
+			if err != nil {
+				t.Fatalf("New() unexpected error: %v", err)
+			}
+			replayed := fetchAll(t, replay, server.URL, nil)
+			for _, path := range []string{"/", "/moved", "/missing"} {
+				if replayed[path] != live[path] {
+					t.Errorf("replayed %s = %q, want %q", path, replayed[path], live[path])
+				}
+			}
+			if live["/large"] != "200 "+strings.Repeat("x", 100) {
+				t.Errorf("live /large = %q, want the whole body", live["/large"])
+			}
+			if want := "200 " + strings.Repeat("x", 65) + " (unexpected EOF)"; replayed["/large"] != want {
+				t.Errorf("replayed /large = %q, want %q", replayed["/large"], want)
+			}
+			if live["Set-Cookie"] != "session=1; Secure" || replayed["Set-Cookie"] != "session="+model.RedactedValue+"; Secure" {
+				t.Errorf("Set-Cookie = %q live and %q replayed, want the cookie value redacted", live["Set-Cookie"], replayed["Set-Cookie"])
+			}
+
+			req, _ := http.NewRequest(http.MethodGet, server.URL+"/unknown", nil)
+			if _, err := replay.RoundTrip(req); !errors.Is(err, ErrNotRecorded) {
+				t.Errorf("RoundTrip() error = %v, want %v", err, ErrNotRecorded)
+			}
+		})
+	}
+}
+
+// fetches the test pages through f, following redirects, and returns the status and body of each and the cookie set by /
+func fetchAll(t *testing.T, f Fetcher, baseURL string, header map[string]string) map[string]string {
+	t.Helper()
+
+	client := &http.Client{Transport: f}
+	results := make(map[string]string)
+	for _, path := range []string{"/", "/moved", "/large", "/missing"} {
+		req, _ := http.NewRequest(http.MethodGet, baseURL+path, nil)
+		for name, value := range header {
+			req.Header.Set(name, value)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s unexpected error: %v", path, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		results[path] = fmt.Sprintf("%d %s", resp.StatusCode, body)
+		if err != nil {
+			results[path] += fmt.Sprintf(" (%v)", err)
+		}
+		if path == "/" {
+			results["Set-Cookie"] = resp.Header.Get("Set-Cookie")
+		}
+	}
+	return results
+}
+
+func TestHARRedactsCredentials(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "site.har")
+	header := http.Header{"Authorization": {"Bearer s3cr3t"}, "Cookie": {"session=c00kie"}, "User-Agent": {"WebAnalyzer"}}
+	if err := writeHAR(archive, []Exchange{{Method: http.MethodGet, URL: "https://example.com/", StatusCode: 200, Header: http.Header{}, RequestHeader: header}}); err != nil {
+		t.Fatalf("writeHAR() unexpected error: %v", err)
+	}
+
+	data, _ := os.ReadFile(archive)
+	if strings.Contains(string(data), "s3cr3t") || strings.Contains(string(data), "c00kie") {
+		t.Errorf("HAR file contains credentials: %s", data)
+	}
+	if !strings.Contains(string(data), model.RedactedValue) || !strings.Contains(string(data), "WebAnalyzer") {
+		t.Errorf("HAR file = %s, want redacted credentials and the user agent", data)
+	}
+}
+
+func TestReplayBrowserHAR(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "browser.har")
+	har := `{"log":{"version":"1.2","creator":{"name":"WebInspector","version":"537.36"},"entries":[{
+		"request":{"method":"GET","url":"https://example.com/"},
+		"response":{"status":200,"headers":[{"name":"Content-Type","value":"text/html"},{"name":"Content-Encoding","value":"gzip"}],
+			"content":{"size":35,"mimeType":"text/html","text":"<html><title>Exported</title></html>"}}}]}}`
+	if err := os.WriteFile(archive, []byte(har), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err := NewReplay(archive)
+	if err != nil {
+		t.Fatalf("NewReplay() unexpected error: %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/", nil)
+	resp, err := replay.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "<html><title>Exported</title></html>" || resp.Header.Get("Content-Encoding") != "" {
+		t.Errorf("response = %q with headers %v, want the decoded body without Content-Encoding", body, resp.Header)
+	}
+}
+
+func TestNewInvalidSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Settings
+	}{
+		{name: "unknown mode", settings: Settings{Mode: "mirror"}},
+		{name: "record without archive", settings: Settings{Mode: ModeRecord}},
+		{name: "replay without archive", settings: Settings{Mode: ModeReplay}},
+		{name: "replay of a missing HAR file", settings: Settings{Mode: ModeReplay, Archive: filepath.Join(t.TempDir(), "missing.har")}},
+		{name: "replay of a missing fixture directory", settings: Settings{Mode: ModeReplay, Archive: filepath.Join(t.TempDir(), "missing")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.settings, http.DefaultTransport); !errors.Is(err, ErrInvalidSettings) {
+				t.Errorf("New() error = %v, want %v", err, ErrInvalidSettings)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package fetcher
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Recorder sends the requests through another fetcher and records every response to a HAR file or a fixture directory.
+// fixtures are written as the responses arrive, a HAR file when the recorder is closed
+type Recorder struct {
+	next         Fetcher
+	archive      string
+	maxBodyBytes int64
+
+	mu        sync.Mutex
+	exchanges []Exchange
+}
+
+func NewRecorder(next Fetcher, archive string, maxBodyBytes int64) (*Recorder, error) {
+	if !isHAR(archive) {
+		if err := os.MkdirAll(archive, 0o755); err != nil {
+			return nil, fmt.Errorf("%w: fixture directory: %v", ErrInvalidSettings, err)
+		}
+	}
+	return &Recorder{next: next, archive: archive, maxBodyBytes: maxBodyBytes}, nil
+}
+
+// RoundTrip passes the response through as it is and records it once the caller closes the body.
+// the archive keeps the first maxBodyBytes+1 bytes the caller read, so a replayed body over the limit is still noticed
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ex := Exchange{
+		Method:        req.Method,
+		URL:           req.URL.String(),
+		StatusCode:    resp.StatusCode,
+		Header:        redactResponseHeader(resp.Header),
+		StartedAt:     start.UTC(),
+		RequestHeader: req.Header.Clone(),
+	}
+	// a HEAD response has no body to read
+	eof := req.Method == http.MethodHead || resp.Body == http.NoBody
+	resp.Body = &recordingBody{ReadCloser: resp.Body, limit: r.maxBodyBytes + 1, eof: eof, done: func(body []byte, complete bool) error {
+		ex.Body = body
+		ex.Truncated = !complete
+		ex.Duration = time.Since(start)
+		if err := r.record(ex); err != nil {
+			return fmt.Errorf("failed to record %s %s: %w", ex.Method, ex.URL, err)
+		}
+		return nil
+	}}
+	return resp, nil
+}
+
+// recordingBody keeps a copy of the first limit bytes read from the body and hands it to done on Close
+type recordingBody struct {
+	io.ReadCloser
+	limit  int64
+	buf    bytes.Buffer
+	eof    bool
+	closed bool
+	done   func(body []byte, complete bool) error
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if keep := min(int64(n), b.limit-int64(b.buf.Len())); keep > 0 {
+		b.buf.Write(p[:keep])
+	}
+	if errors.Is(err, io.EOF) {
+		b.eof = true
+	}
+	return n, err
+}
+
+// Close records the body, which is complete when the caller read it to the end within the limit
+func (b *recordingBody) Close() error {
+	err := b.ReadCloser.Close()
+	if b.closed {
+		return err
+	}
+	b.closed = true
+	complete := b.eof && int64(b.buf.Len()) < b.limit
+	if recordErr := b.done(b.buf.Bytes(), complete); recordErr != nil {
+		return recordErr
+	}
+	return err
+}
+
+func (r *Recorder) record(ex Exchange) error {
+	if !isHAR(r.archive) {
+		return writeFixture(r.archive, ex)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exchanges = append(r.exchanges, ex)
+	return nil
+}
+
+// Close writes the HAR file and closes the wrapped fetcher
+func (r *Recorder) Close() error {
+	defer r.next.Close()
+	if !isHAR(r.archive) {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return writeHAR(r.archive, r.exchanges)
+}
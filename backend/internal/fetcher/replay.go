@@ -0,0 +1,82 @@
+package fetcher
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// Replay serves the responses of a recorded archive without touching the network.
+// replayed responses carry no TLS connection state
+type Replay struct {
+	exchanges map[string]Exchange
+}
+
+// NewReplay loads a HAR file or a fixture directory, the last recording of a request wins
+func NewReplay(archive string) (*Replay, error) {
+	var exchanges []Exchange
+	var err error
+	if isHAR(archive) {
+		exchanges, err = readHAR(archive)
+	} else {
+		exchanges, err = readFixtures(archive)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+	}
+
+	r := &Replay{exchanges: make(map[string]Exchange, len(exchanges))}
+	for _, ex := range exchanges {
+		r.exchanges[exchangeKey(ex.Method, ex.URL)] = ex
+	}
+	return r, nil
+}
+
+func (r *Replay) RoundTrip(req *http.Request) (*http.Response, error) {
+	ex, ok := r.exchanges[exchangeKey(req.Method, req.URL.String())]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, req.Method, req.URL)
+	}
+
+	header := ex.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	var body io.Reader = bytes.NewReader(ex.Body)
+	contentLength := int64(len(ex.Body))
+	if ex.Truncated {
+		// the rest of the body was never recorded, readers see it end early as on a dropped connection
+		body = io.MultiReader(body, truncatedReader{})
+	}
+	if ex.Truncated || req.Method == http.MethodHead {
+		contentLength = -1
+		if length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); err == nil {
+			contentLength = length
+		}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", ex.StatusCode, http.StatusText(ex.StatusCode)),
+		StatusCode:    ex.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(body),
+		ContentLength: contentLength,
+		Request:       req,
+	}, nil
+}
+
+func (r *Replay) Close() error {
+	return nil
+}
+
+// truncatedReader ends a replayed body that was cut when it was recorded
+type truncatedReader struct{}
+
+func (truncatedReader) Read([]byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
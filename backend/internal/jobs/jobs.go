@@ -0,0 +1,226 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+	"webanalyzer/internal/log"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/service"
+)
+
+var (
+	ErrQueueFull   = errors.New("job queue is full")
+	ErrJobNotFound = errors.New("job not found")
+)
+
+// Queue is the process wide job queue, set up by Init
+var Queue *JobQueue
+
+// AnalyzeFunc runs the analysis for a single job
+type AnalyzeFunc func(ctx context.Context, targetURL string, opts model.AnalyzeOptions, onEvent service.EventFunc) (*model.WebpageAnalysis, error)
+
+type entry struct {
+	job    model.AnalysisJob
+	cancel context.CancelFunc
+}
+
+// JobQueue is a bounded in-process queue of analysis jobs processed by a fixed pool of workers
+type JobQueue struct {
+	mu      sync.Mutex
+	jobs    map[string]*entry
+	pending chan string
+	analyze AnalyzeFunc
+	ttl     time.Duration
+	ctx     context.Context
+	stop    context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func Init(workers, capacity int, ttl time.Duration) {
+	Queue = New(workers, capacity, ttl, service.AnalyzePageWithEvents)
+}
+
+// New creates a job queue and starts its workers.
+// finished jobs are kept for ttl before they are removed
+func New(workers, capacity int, ttl time.Duration, analyze AnalyzeFunc) *JobQueue {
+	ctx, stop := context.WithCancel(context.Background())
+	q := &JobQueue{
+		jobs:    make(map[string]*entry),
+		pending: make(chan string, capacity),
+		analyze: analyze,
+		ttl:     ttl,
+		ctx:     ctx,
+		stop:    stop,
+	}
+
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+
+	go q.cleanup()
+
+	return q
+}
+
+// Submit enqueues a new analysis job and returns a snapshot of it
+func (q *JobQueue) Submit(targetURL string, opts model.AnalyzeOptions) (model.AnalysisJob, error) {
+	now := time.Now()
+	e := &entry{
+		job: model.AnalysisJob{
+			ID:        uuid.New().String(),
+			URL:       targetURL,
+			Options:   opts,
+			State:     model.JobQueued,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	select {
+	case q.pending <- e.job.ID:
+		q.jobs[e.job.ID] = e
+	default:
+		return model.AnalysisJob{}, ErrQueueFull
+	}
+
+	return e.job, nil
+}
+
+// Get returns a snapshot of the job with the given id
+func (q *JobQueue) Get(id string) (model.AnalysisJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	e, ok := q.jobs[id]
+	if !ok {
+		return model.AnalysisJob{}, ErrJobNotFound
+	}
+	return e.job, nil
+}
+
+// Cancel stops a queued or running job. Finished jobs are left untouched
+func (q *JobQueue) Cancel(id string) (model.AnalysisJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	e, ok := q.jobs[id]
+	if !ok {
+		return model.AnalysisJob{}, ErrJobNotFound
+	}
+	if e.job.IsFinished() {
+		return e.job, nil
+	}
+
+	if e.cancel != nil {
+		e.cancel()
+	}
+	q.finish(e, model.JobCanceled, nil, context.Canceled)
+	return e.job, nil
+}
+
+// Stop cancels all running jobs and waits for the workers to exit
+func (q *JobQueue) Stop() {
+	q.stop()
+	q.wg.Wait()
+}
+
+func (q *JobQueue) worker() {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case id := <-q.pending:
+			q.run(id)
+		}
+	}
+}
+
+func (q *JobQueue) run(id string) {
+	q.mu.Lock()
+	e, ok := q.jobs[id]
+	if !ok || e.job.IsFinished() {
+		q.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(q.ctx)
+	defer cancel()
+	e.cancel = cancel
+	targetURL, opts := e.job.URL, e.job.Options
+	q.mu.Unlock()
+
+	onEvent := func(event model.AnalysisEvent) {
+		if event.Type != model.EventStage {
+			return
+		}
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if e.job.IsFinished() {
+			return
+		}
+		e.job.State = event.Stage
+		if partial, ok := event.Data.(*model.WebpageAnalysis); ok {
+			e.job.Result = partial
+		}
+		e.job.UpdatedAt = time.Now()
+	}
+
+	result, err := q.analyze(ctx, targetURL, opts, onEvent)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if e.job.IsFinished() {
+		return
+	}
+	switch {
+	case err == nil:
+		q.finish(e, model.JobDone, result, nil)
+	case errors.Is(err, context.Canceled):
+		q.finish(e, model.JobCanceled, nil, err)
+	default:
+		log.Logger.Warn("analysis job failed", zap.String("job_id", id), zap.Error(err))
+		q.finish(e, model.JobFailed, nil, err)
+	}
+}
+
+// moves the job into a terminal state. q.mu must be held
+func (q *JobQueue) finish(e *entry, state model.JobState, result *model.WebpageAnalysis, err error) {
+	now := time.Now()
+	e.job.State = state
+	if result != nil {
+		e.job.Result = result
+	}
+	if err != nil {
+		e.job.Error = err.Error()
+	}
+	e.job.UpdatedAt = now
+	e.job.FinishedAt = &now
+}
+
+// removes finished jobs once their ttl has expired
+func (q *JobQueue) cleanup() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case <-ticker.C:
+			q.mu.Lock()
+			for id, e := range q.jobs {
+				if e.job.FinishedAt != nil && time.Since(*e.job.FinishedAt) > q.ttl {
+					delete(q.jobs, id)
+				}
+			}
+			q.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+	"webanalyzer/internal/log"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/service"
+)
+
+func waitForState(t *testing.T, q *JobQueue, id string, state model.JobState) model.AnalysisJob {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := q.Get(id)
+		if err != nil {
+			t.Fatalf("Get() unexpected error: %v", err)
+		}
+		if job.State == state {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	job, _ := q.Get(id)
+	t.Fatalf("job state = %s, want %s", job.State, state)
+	return job
+}
+
+func TestJobQueueLifecycle(t *testing.T) {
+	log.Logger = zap.NewNop()
+
+	release := make(chan struct{})
+	analyze := func(ctx context.Context, targetURL string, opts model.AnalyzeOptions, onEvent service.EventFunc) (*model.WebpageAnalysis, error) {
+		onEvent(model.AnalysisEvent{Type: model.EventStage, Stage: model.JobFetching, Data: &model.WebpageAnalysis{}})
+		onEvent(model.AnalysisEvent{Type: model.EventTitle, Data: "Partial"})
+		onEvent(model.AnalysisEvent{Type: model.EventStage, Stage: model.JobCheckingLinks, Data: &model.WebpageAnalysis{PageTitle: "Partial"}})
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if targetURL == "https://fail.example.com" {
+			return nil, errors.New("unexpected status code: 500")
+		}
+		return &model.WebpageAnalysis{PageTitle: "Done", InternalLinkCount: 2}, nil
+	}
+
+	q := New(1, 10, time.Hour, analyze)
+	defer q.Stop()
+
+	job, err := q.Submit("https://example.com", model.AnalyzeOptions{})
+	if err != nil {
+		t.Fatalf("Submit() unexpected error: %v", err)
+	}
+
+	partial := waitForState(t, q, job.ID, model.JobCheckingLinks)
+	if partial.Result == nil || partial.Result.PageTitle != "Partial" {
+		t.Errorf("partial result = %+v, want page title %q", partial.Result, "Partial")
+	}
+
+	release <- struct{}{}
+	done := waitForState(t, q, job.ID, model.JobDone)
+	if done.Result == nil || done.Result.PageTitle != "Done" || done.FinishedAt == nil {
+		t.Errorf("finished job = %+v, want result with title %q", done, "Done")
+	}
+
+	failing, _ := q.Submit("https://fail.example.com", model.AnalyzeOptions{})
+	waitForState(t, q, failing.ID, model.JobCheckingLinks)
+	release <- struct{}{}
+	failed := waitForState(t, q, failing.ID, model.JobFailed)
+	if failed.Error == "" {
+		t.Error("failed job has no error message")
+	}
+
+	if _, err := q.Get("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Get(missing) error = %v, want %v", err, ErrJobNotFound)
+	}
+}
+
+func TestJobQueueCancel(t *testing.T) {
+	log.Logger = zap.NewNop()
+
+	started := make(chan struct{}, 1)
+	analyze := func(ctx context.Context, targetURL string, opts model.AnalyzeOptions, onEvent service.EventFunc) (*model.WebpageAnalysis, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	q := New(1, 1, time.Hour, analyze)
+	defer q.Stop()
+
+	running, _ := q.Submit("https://example.com/running", model.AnalyzeOptions{})
+	<-started
+
+	queued, err := q.Submit("https://example.com/queued", model.AnalyzeOptions{})
+	if err != nil {
+		t.Fatalf("Submit() unexpected error: %v", err)
+	}
+
+	if _, err := q.Submit("https://example.com/overflow", model.AnalyzeOptions{}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Submit() on full queue error = %v, want %v", err, ErrQueueFull)
+	}
+
+	for _, id := range []string{queued.ID, running.ID} {
+		job, err := q.Cancel(id)
+		if err != nil {
+			t.Fatalf("Cancel() unexpected error: %v", err)
+		}
+		if job.State != model.JobCanceled {
+			t.Errorf("Cancel() state = %s, want %s", job.State, model.JobCanceled)
+		}
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if job, _ := q.Get(queued.ID); job.State != model.JobCanceled {
+		t.Errorf("queued job state = %s, want %s", job.State, model.JobCanceled)
+	}
+}
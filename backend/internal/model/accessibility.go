@@ -0,0 +1,15 @@
+package model
+
+// Accessibility holds the result of the basic WCAG checks run on the page
+type Accessibility struct {
+	Issues           []AccessibilityIssue `json:"issues"`
+	IssueCounts      map[string]int       `json:"issue_counts"`
+	MissingLandmarks []string             `json:"missing_landmarks"`
+}
+
+// AccessibilityIssue is a single failed check, Selector locates the offending element
+type AccessibilityIssue struct {
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+	Selector string `json:"selector"`
+}
@@ -0,0 +1,47 @@
+package model
+
+type EventType string
+
+const (
+	EventStage          EventType = "stage"
+	EventFetched        EventType = "fetched"
+	EventHTMLVersion    EventType = "html_version"
+	EventDoctype        EventType = "doctype"
+	EventTitle          EventType = "title"
+	EventHeadings       EventType = "headings"
+	EventHeadingOutline EventType = "heading_outline"
+	EventLoginForm      EventType = "login_form"
+	EventSEO            EventType = "seo"
+	EventSocial         EventType = "social"
+	EventStructuredData EventType = "structured_data"
+	EventAccessibility  EventType = "accessibility"
+	EventResources      EventType = "resources"
+	EventSecurity       EventType = "security"
+	EventLink           EventType = "link"
+	EventSummary        EventType = "summary"
+	EventError          EventType = "error"
+)
+
+// AnalysisEvent is emitted by the analyzer while a page analysis is in progress.
+// for stage events Data holds a snapshot of the partial *WebpageAnalysis
+type AnalysisEvent struct {
+	Type  EventType   `json:"type"`
+	Stage JobState    `json:"stage,omitempty"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+// FetchInfo describes the fetched target document
+type FetchInfo struct {
+	URL           string `json:"url"`
+	FinalURL      string `json:"final_url"`
+	ContentLength int    `json:"content_length"`
+	Truncated     bool   `json:"truncated"`
+	Encoding      string `json:"encoding"`
+}
+
+// LinkProgress reports a single finished link check
+type LinkProgress struct {
+	Checked int        `json:"checked"`
+	Total   int        `json:"total"`
+	Link    LinkDetail `json:"link"`
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+type JobState string
+
+const (
+	JobQueued        JobState = "queued"
+	JobFetching      JobState = "fetching"
+	JobCheckingLinks JobState = "checking_links"
+	JobDone          JobState = "done"
+	JobFailed        JobState = "failed"
+	JobCanceled      JobState = "canceled"
+)
+
+// AnalysisJob is an asynchronous page analysis tracked by the job queue
+type AnalysisJob struct {
+	ID         string           `json:"id"`
+	URL        string           `json:"url"`
+	Options    AnalyzeOptions   `json:"options"`
+	State      JobState         `json:"state"`
+	Error      string           `json:"error,omitempty"`
+	Result     *WebpageAnalysis `json:"result,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty"`
+}
+
+// IsFinished reports whether the job reached a terminal state
+func (j *AnalysisJob) IsFinished() bool {
+	return j.State == JobDone || j.State == JobFailed || j.State == JobCanceled
+}
+
+type CreateJobRequest struct {
+	URL     string         `json:"url"`
+	Options AnalyzeOptions `json:"options"`
+}
@@ -1,13 +1,28 @@
 package model
 
+import "encoding/json"
+
 type WebpageAnalysis struct {
-	HTMLVersion           string        `json:"html_version"`
-	PageTitle             string        `json:"page_title"`
-	HeadingCounts         HeadingCounts `json:"heading_counts"`
-	InternalLinkCount     int           `json:"internal_link_count"`
-	ExternalLinkCount     int           `json:"external_link_count"`
-	InaccessibleLinkCount int           `json:"inaccessible_link_count"`
-	HasLoginForm          bool          `json:"has_login_form"`
+	HTMLVersion           string          `json:"html_version"`
+	PageTitle             string          `json:"page_title"`
+	HeadingCounts         HeadingCounts   `json:"heading_counts"`
+	InternalLinkCount     int             `json:"internal_link_count"`
+	ExternalLinkCount     int             `json:"external_link_count"`
+	InaccessibleLinkCount int             `json:"inaccessible_link_count"`
+	HasLoginForm          bool            `json:"has_login_form"`
+	Links                 []LinkDetail    `json:"links,omitempty"`
+	Robots                *RobotsStatus   `json:"robots,omitempty"`
+	SEO                   *SEO            `json:"seo,omitempty"`
+	Social                *SocialMetadata `json:"social,omitempty"`
+	StructuredData        *StructuredData `json:"structured_data,omitempty"`
+	Accessibility         *Accessibility  `json:"accessibility,omitempty"`
+	HeadingOutline        *HeadingOutline `json:"heading_outline,omitempty"`
+	Doctype               *Doctype        `json:"doctype,omitempty"`
+	Resources             *Resources      `json:"resources,omitempty"`
+	Security              *Security       `json:"security,omitempty"`
+	Redirects             *RedirectChain  `json:"redirects,omitempty"`
+	Content               *Content        `json:"content,omitempty"`
+	Encoding              *Encoding       `json:"encoding,omitempty"`
 }
 
 type HeadingCounts struct {
@@ -18,3 +33,73 @@ type HeadingCounts struct {
 	H5 int `json:"h5"`
 	H6 int `json:"h6"`
 }
+
+// HeadingOutline is the nested document outline built from the h1-h6 elements in document order
+type HeadingOutline struct {
+	Headings []*HeadingNode `json:"headings"`
+	Issues   []HeadingIssue `json:"issues"`
+}
+
+// HeadingNode is a heading and the lower level headings that follow it
+type HeadingNode struct {
+	Level    int            `json:"level"`
+	Text     string         `json:"text"`
+	Children []*HeadingNode `json:"children,omitempty"`
+}
+
+// HeadingIssue is a structural problem of the outline, Type is one of
+// missing_h1, multiple_h1, level_jump or empty_heading
+type HeadingIssue struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Level   int    `json:"level,omitempty"`
+	Text    string `json:"text,omitempty"`
+}
+
+// LinkDetail describes a single <a href> found in the page and the outcome of its accessibility check
+type LinkDetail struct {
+	Href         string `json:"href"`
+	ResolvedURL  string `json:"resolved_url"`
+	IsInternal   bool   `json:"is_internal"`
+	AnchorText   string `json:"anchor_text"`
+	IsAccessible bool   `json:"is_accessible"`
+	Skipped      bool   `json:"skipped,omitempty"`
+	StatusCode   int    `json:"status_code,omitempty"`
+	FinalURL     string `json:"final_url,omitempty"`
+	ErrorClass   string `json:"error_class,omitempty"`
+	LatencyMs    int64  `json:"latency_ms"`
+}
+
+// AnalyzeOptions controls the optional parts of a page analysis
+type AnalyzeOptions struct {
+	IncludeLinks   bool          `json:"include_links"`
+	IgnoreRobots   bool          `json:"ignore_robots"`
+	CheckResources bool          `json:"check_resources"`
+	UserAgent      string        `json:"user_agent,omitempty"`
+	Headers        RequestValues `json:"headers,omitempty"`
+	Cookies        RequestValues `json:"cookies,omitempty"`
+}
+
+// RedactedValue replaces the header and cookie values whenever options are serialized
+const RedactedValue = "[REDACTED]"
+
+// RequestValues are header or cookie values sent to the analyzed host.
+// they can carry credentials, so the values are redacted when written out to logs or responses
+type RequestValues map[string]string
+
+func (v RequestValues) MarshalJSON() ([]byte, error) {
+	redacted := make(map[string]string, len(v))
+	for name := range v {
+		redacted[name] = RedactedValue
+	}
+	return json.Marshal(redacted)
+}
+
+// RobotsStatus tells whether robots.txt allows our user agent to fetch the analyzed page
+type RobotsStatus struct {
+	RobotsURL         string  `json:"robots_url"`
+	UserAgent         string  `json:"user_agent"`
+	Allowed           bool    `json:"allowed"`
+	Ignored           bool    `json:"ignored"`
+	CrawlDelaySeconds float64 `json:"crawl_delay_seconds,omitempty"`
+}
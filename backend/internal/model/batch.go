@@ -0,0 +1,15 @@
+package model
+
+type BatchAnalyzeRequest struct {
+	URLs    []string       `json:"urls"`
+	Options AnalyzeOptions `json:"options"`
+}
+
+// BatchResult is the outcome of analyzing one URL of a batch.
+// Index is the position of the URL in the request
+type BatchResult struct {
+	Index  int              `json:"index"`
+	URL    string           `json:"url"`
+	Result *WebpageAnalysis `json:"result,omitempty"`
+	Error  string           `json:"error,omitempty"`
+}
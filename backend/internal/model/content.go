@@ -0,0 +1,14 @@
+package model
+
+// Content describes the body served for the analyzed page and how much of it was analyzed
+type Content struct {
+	ContentType     string `json:"content_type"`
+	MediaType       string `json:"media_type"`
+	IsHTML          bool   `json:"is_html"`
+	ContentEncoding string `json:"content_encoding,omitempty"`
+	// TransferBytes were read from the connection, BodyBytes are the decoded bytes that were analyzed
+	TransferBytes int64    `json:"transfer_bytes"`
+	BodyBytes     int      `json:"body_bytes"`
+	Truncated     bool     `json:"truncated"`
+	Warnings      []string `json:"warnings"`
+}
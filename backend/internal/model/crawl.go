@@ -0,0 +1,44 @@
+package model
+
+type CrawlRequest struct {
+	URL      string         `json:"url"`
+	MaxDepth int            `json:"max_depth"`
+	MaxPages int            `json:"max_pages"`
+	Options  AnalyzeOptions `json:"options"`
+}
+
+// CrawledPage is the analysis of a single page visited by the crawler
+type CrawledPage struct {
+	URL      string           `json:"url"`
+	Depth    int              `json:"depth"`
+	Analysis *WebpageAnalysis `json:"analysis,omitempty"`
+	Error    string           `json:"error,omitempty"`
+}
+
+// BrokenLink is an internal link that failed its accessibility check
+type BrokenLink struct {
+	SourceURL  string `json:"source_url"`
+	TargetURL  string `json:"target_url"`
+	StatusCode int    `json:"status_code,omitempty"`
+	ErrorClass string `json:"error_class,omitempty"`
+}
+
+// SiteReport aggregates the per page analyses of a crawl, OrphanPages are the sitemap URLs no crawled page links to,
+// left empty when the crawl is truncated
+type SiteReport struct {
+	StartURL              string        `json:"start_url"`
+	PagesCrawled          int           `json:"pages_crawled"`
+	PagesFailed           int           `json:"pages_failed"`
+	MaxDepthReached       int           `json:"max_depth_reached"`
+	Truncated             bool          `json:"truncated"`
+	PagesMissingTitle     []string      `json:"pages_missing_title"`
+	PagesWithoutH1        []string      `json:"pages_without_h1"`
+	PagesWithMultipleH1   []string      `json:"pages_with_multiple_h1"`
+	OrphanPages           []string      `json:"orphan_pages"`
+	BrokenInternalLinks   []BrokenLink  `json:"broken_internal_links"`
+	HeadingTotals         HeadingCounts `json:"heading_totals"`
+	InternalLinkCount     int           `json:"internal_link_count"`
+	ExternalLinkCount     int           `json:"external_link_count"`
+	InaccessibleLinkCount int           `json:"inaccessible_link_count"`
+	Pages                 []CrawledPage `json:"pages"`
+}
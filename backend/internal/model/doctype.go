@@ -0,0 +1,16 @@
+package model
+
+// Doctype describes the document type declaration of a page and the rendering mode
+// a browser picks for it
+type Doctype struct {
+	Present       bool     `json:"present"`
+	Name          string   `json:"name,omitempty"`
+	PublicID      string   `json:"public_id,omitempty"`
+	SystemID      string   `json:"system_id,omitempty"`
+	Version       string   `json:"version"`
+	Variant       string   `json:"variant,omitempty"`
+	RenderingMode string   `json:"rendering_mode"`
+	ContentType   string   `json:"content_type,omitempty"`
+	IsXHTML       bool     `json:"is_xhtml"`
+	Warnings      []string `json:"warnings"`
+}
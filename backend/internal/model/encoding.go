@@ -0,0 +1,14 @@
+package model
+
+// Encoding describes how the character encoding of the page was determined.
+// Source is one of bom, header, meta or default, the declared encodings are the canonical names of what the page claims
+type Encoding struct {
+	Detected   string   `json:"detected"`
+	Source     string   `json:"source"`
+	BOM        string   `json:"bom,omitempty"`
+	Header     string   `json:"header,omitempty"`
+	Meta       string   `json:"meta,omitempty"`
+	Transcoded bool     `json:"transcoded"`
+	Mismatch   bool     `json:"mismatch"`
+	Warnings   []string `json:"warnings"`
+}
@@ -0,0 +1,18 @@
+package model
+
+// RedirectChain lists the redirects followed before the analyzed page was reached
+type RedirectChain struct {
+	Hops      []RedirectHop `json:"hops"`
+	FinalURL  string        `json:"final_url"`
+	Downgrade bool          `json:"downgrade"`
+	Warnings  []string      `json:"warnings"`
+}
+
+// RedirectHop is a single redirect response, Location is the raw header and Target the URL it resolves to
+type RedirectHop struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	Location   string `json:"location"`
+	Target     string `json:"target"`
+	DurationMs int64  `json:"duration_ms"`
+}
@@ -0,0 +1,29 @@
+package model
+
+// Resources is the inventory of the subresources referenced by a page with the approximate page weight
+type Resources struct {
+	Items            []Resource     `json:"items"`
+	CountsByType     map[string]int `json:"counts_by_type"`
+	FirstPartyCount  int            `json:"first_party_count"`
+	ThirdPartyCount  int            `json:"third_party_count"`
+	Checked          bool           `json:"checked"`
+	HTMLBytes        int64          `json:"html_bytes"`
+	ResourceBytes    int64          `json:"resource_bytes"`
+	TotalBytes       int64          `json:"total_bytes"`
+	UnknownSizeCount int            `json:"unknown_size_count"`
+}
+
+// Resource is a subresource referenced by the page, resolved against the page URL.
+// the check fields are only set when resource checks were requested
+type Resource struct {
+	Type         string `json:"type"`
+	Tag          string `json:"tag"`
+	Attribute    string `json:"attribute"`
+	URL          string `json:"url"`
+	ResolvedURL  string `json:"resolved_url"`
+	IsThirdParty bool   `json:"is_third_party"`
+	IsAccessible bool   `json:"is_accessible"`
+	StatusCode   int    `json:"status_code,omitempty"`
+	ErrorClass   string `json:"error_class,omitempty"`
+	SizeBytes    int64  `json:"size_bytes,omitempty"`
+}
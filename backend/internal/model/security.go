@@ -0,0 +1,93 @@
+package model
+
+import "time"
+
+// Security holds the security findings about the analyzed page
+type Security struct {
+	MixedContent []MixedContentItem `json:"mixed_content"`
+	Headers      *SecurityHeaders   `json:"headers,omitempty"`
+	TLS          *TLSInfo           `json:"tls,omitempty"`
+}
+
+// MixedContentItem is an http:// subresource or form action referenced by an HTTPS page.
+// Category is active (scripts, stylesheets, frames) which browsers block, passive (images, media)
+// which browsers upgrade or load with a warning, or form for insecure form submissions
+type MixedContentItem struct {
+	URL       string `json:"url"`
+	Tag       string `json:"tag"`
+	Attribute string `json:"attribute"`
+	Type      string `json:"type"`
+	Category  string `json:"category"`
+}
+
+// SecurityHeaders grades the security related response headers of the analyzed page.
+// Score is out of 100 and Grade goes from A to F
+type SecurityHeaders struct {
+	Score                   int           `json:"score"`
+	Grade                   string        `json:"grade"`
+	StrictTransportSecurity HSTSCheck     `json:"strict_transport_security"`
+	ContentSecurityPolicy   CSPCheck      `json:"content_security_policy"`
+	XFrameOptions           HeaderCheck   `json:"x_frame_options"`
+	XContentTypeOptions     HeaderCheck   `json:"x_content_type_options"`
+	ReferrerPolicy          HeaderCheck   `json:"referrer_policy"`
+	PermissionsPolicy       HeaderCheck   `json:"permissions_policy"`
+	Cookies                 []CookieCheck `json:"cookies"`
+}
+
+// HeaderCheck is the outcome of checking a single response header
+type HeaderCheck struct {
+	Header   string   `json:"header"`
+	Present  bool     `json:"present"`
+	Value    string   `json:"value,omitempty"`
+	Passed   bool     `json:"passed"`
+	Warnings []string `json:"warnings"`
+}
+
+// HSTSCheck is the Strict-Transport-Security check with its parsed directives
+type HSTSCheck struct {
+	HeaderCheck
+	MaxAgeSeconds     int64 `json:"max_age_seconds"`
+	IncludeSubDomains bool  `json:"include_subdomains"`
+	Preload           bool  `json:"preload"`
+}
+
+// CSPCheck is the Content-Security-Policy check with the policy split into directives
+type CSPCheck struct {
+	HeaderCheck
+	ReportOnly bool                `json:"report_only"`
+	Directives map[string][]string `json:"directives,omitempty"`
+}
+
+// CookieCheck reports the security attributes of a cookie set by the page, the value is never reported
+type CookieCheck struct {
+	Name     string   `json:"name"`
+	Secure   bool     `json:"secure"`
+	HttpOnly bool     `json:"http_only"`
+	SameSite string   `json:"same_site,omitempty"`
+	Warnings []string `json:"warnings"`
+}
+
+// TLSInfo describes the TLS connection of an HTTPS page and the certificate chain sent by the server
+type TLSInfo struct {
+	Version         string            `json:"version"`
+	CipherSuite     string            `json:"cipher_suite"`
+	ALPN            string            `json:"alpn,omitempty"`
+	ServerName      string            `json:"server_name"`
+	HostnameMatch   bool              `json:"hostname_match"`
+	DaysUntilExpiry int               `json:"days_until_expiry"`
+	Certificates    []CertificateInfo `json:"certificates"`
+	Warnings        []string          `json:"warnings"`
+}
+
+// CertificateInfo is a certificate of the chain, the leaf certificate comes first
+type CertificateInfo struct {
+	Subject            string    `json:"subject"`
+	Issuer             string    `json:"issuer"`
+	SANs               []string  `json:"sans"`
+	SerialNumber       string    `json:"serial_number"`
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	IsCA               bool      `json:"is_ca"`
+	NotBefore          time.Time `json:"not_before"`
+	NotAfter           time.Time `json:"not_after"`
+	DaysUntilExpiry    int       `json:"days_until_expiry"`
+}
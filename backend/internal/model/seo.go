@@ -0,0 +1,22 @@
+package model
+
+// SEO holds the search engine related metadata of a page
+type SEO struct {
+	Title                 string              `json:"title"`
+	TitleLength           int                 `json:"title_length"`
+	TitleCount            int                 `json:"title_count"`
+	MetaDescription       string              `json:"meta_description"`
+	MetaDescriptionLength int                 `json:"meta_description_length"`
+	MetaRobots            []string            `json:"meta_robots"`
+	Googlebot             []string            `json:"googlebot"`
+	Canonical             string              `json:"canonical,omitempty"`
+	IsSelfCanonical       bool                `json:"is_self_canonical"`
+	Hreflang              []HreflangAlternate `json:"hreflang"`
+	Warnings              []string            `json:"warnings"`
+}
+
+// HreflangAlternate is a <link rel="alternate" hreflang="..."> of the page
+type HreflangAlternate struct {
+	Lang string `json:"lang"`
+	URL  string `json:"url"`
+}
@@ -0,0 +1,32 @@
+package model
+
+type SitemapAuditRequest struct {
+	URL        string         `json:"url"`
+	SitemapURL string         `json:"sitemap_url"`
+	MaxURLs    int            `json:"max_urls"`
+	Options    AnalyzeOptions `json:"options"`
+}
+
+// SitemapURLResult is the audit of a single URL listed in a sitemap
+type SitemapURLResult struct {
+	URL              string           `json:"url"`
+	LastMod          string           `json:"lastmod,omitempty"`
+	StatusCode       int              `json:"status_code,omitempty"`
+	FinalURL         string           `json:"final_url,omitempty"`
+	Redirected       bool             `json:"redirected"`
+	LinkedInternally bool             `json:"linked_internally"`
+	Analysis         *WebpageAnalysis `json:"analysis,omitempty"`
+	Error            string           `json:"error,omitempty"`
+}
+
+// SitemapReport is the result of analyzing every URL listed in the sitemaps of a site
+type SitemapReport struct {
+	Sitemaps       []string           `json:"sitemaps"`
+	SitemapErrors  []string           `json:"sitemap_errors,omitempty"`
+	URLCount       int                `json:"url_count"`
+	Truncated      bool               `json:"truncated"`
+	NonOKURLs      []string           `json:"non_ok_urls"`
+	RedirectedURLs []string           `json:"redirected_urls"`
+	UnlinkedURLs   []string           `json:"unlinked_urls"`
+	URLs           []SitemapURLResult `json:"urls"`
+}
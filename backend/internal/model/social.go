@@ -0,0 +1,42 @@
+package model
+
+// SocialMetadata holds the Open Graph and Twitter Card properties that control how a shared link is rendered
+type SocialMetadata struct {
+	OpenGraph        OpenGraph   `json:"open_graph"`
+	Twitter          TwitterCard `json:"twitter"`
+	MissingOpenGraph []string    `json:"missing_open_graph"`
+	MissingTwitter   []string    `json:"missing_twitter"`
+}
+
+type OpenGraph struct {
+	Title       string        `json:"title,omitempty"`
+	Type        string        `json:"type,omitempty"`
+	URL         string        `json:"url,omitempty"`
+	Description string        `json:"description,omitempty"`
+	SiteName    string        `json:"site_name,omitempty"`
+	Locale      string        `json:"locale,omitempty"`
+	Images      []SocialImage `json:"images"`
+}
+
+type TwitterCard struct {
+	Card        string       `json:"card,omitempty"`
+	Site        string       `json:"site,omitempty"`
+	Creator     string       `json:"creator,omitempty"`
+	Title       string       `json:"title,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Image       *SocialImage `json:"image,omitempty"`
+}
+
+// SocialImage is an image referenced by og:image or twitter:image, resolved against the page URL
+type SocialImage struct {
+	URL          string `json:"url"`
+	SecureURL    string `json:"secure_url,omitempty"`
+	Type         string `json:"type,omitempty"`
+	Width        string `json:"width,omitempty"`
+	Height       string `json:"height,omitempty"`
+	Alt          string `json:"alt,omitempty"`
+	IsAccessible bool   `json:"is_accessible"`
+	Skipped      bool   `json:"skipped,omitempty"`
+	StatusCode   int    `json:"status_code,omitempty"`
+	ErrorClass   string `json:"error_class,omitempty"`
+}
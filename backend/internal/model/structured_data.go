@@ -0,0 +1,27 @@
+package model
+
+// StructuredData holds the JSON-LD, Microdata and RDFa entities found in a page
+type StructuredData struct {
+	Entities []StructuredEntity    `json:"entities"`
+	Types    []string              `json:"types"`
+	Errors   []StructuredDataError `json:"errors"`
+}
+
+// StructuredEntity is a single item normalized from any of the supported formats.
+// nested items are kept inside Properties
+type StructuredEntity struct {
+	Format     string                 `json:"format"`
+	Types      []string               `json:"types"`
+	ID         string                 `json:"id,omitempty"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// StructuredDataError describes a block that could not be parsed.
+// Block is the index of the <script> element among the JSON-LD blocks, Line and Column point into its content
+type StructuredDataError struct {
+	Format  string `json:"format"`
+	Block   int    `json:"block"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
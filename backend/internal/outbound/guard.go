@@ -0,0 +1,194 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ErrBlockedAddress is returned for requests that would reach a loopback, private, link-local, multicast or metadata address
+var ErrBlockedAddress = errors.New("destination address is not allowed")
+
+// ranges blocked on top of the loopback, private, link-local, multicast and unspecified ones.
+// the AWS, GCP and Azure metadata services live in 169.254.0.0/16 and fd00:ec2::/32, which are already link-local and private
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // this network
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier grade NAT, hosts the Alibaba Cloud metadata service
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // reserved and broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, may embed any of the above
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local NAT64
+}
+
+// AllowList holds the destinations reachable even when private networks are blocked
+type AllowList struct {
+	Networks []netip.Prefix
+	// Hosts are exact host names, or domains including their subdomains when they start with a dot
+	Hosts []string
+}
+
+// ParseAllowList parses a comma separated list of CIDR ranges, IP addresses and host names
+func ParseAllowList(s string) (AllowList, error) {
+	var allow AllowList
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+		case strings.Contains(entry, "/"):
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return AllowList{}, fmt.Errorf("%w: allow-list range %q", ErrInvalidSettings, entry)
+			}
+			allow.Networks = append(allow.Networks, prefix.Masked())
+		default:
+			if addr, err := netip.ParseAddr(entry); err == nil {
+				allow.Networks = append(allow.Networks, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			} else {
+				allow.Hosts = append(allow.Hosts, entry)
+			}
+		}
+	}
+	return allow, nil
+}
+
+func (a AllowList) allowsHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, allowed := range a.Hosts {
+		if host == allowed || (strings.HasPrefix(allowed, ".") && (strings.HasSuffix(host, allowed) || host == allowed[1:])) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a AllowList) allowsAddr(addr netip.Addr) bool {
+	for _, prefix := range a.Networks {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBlockedAddr reports whether addr is a loopback, private, link-local, multicast, metadata or reserved address
+func IsBlockedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsMulticast() ||
+		addr.IsUnspecified() || !addr.IsValid() {
+		return true
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// guardedDialer resolves every host it connects to and only dials the permitted addresses.
+// it dials the checked IP itself, so a second DNS answer cannot swap in another address
+type guardedDialer struct {
+	dialer    *net.Dialer
+	resolver  *net.Resolver
+	allow     AllowList
+	overrides map[string]string
+
+	// proxy addresses are configured by the operator and always reachable, like the host overrides
+	proxies sync.Map
+}
+
+func (d *guardedDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if ip, ok := d.overrides[strings.ToLower(host)]; ok {
+		return d.dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+	}
+	if _, ok := d.proxies.Load(addr); ok || d.allow.allowsHost(host) {
+		return d.dialer.DialContext(ctx, network, addr)
+	}
+
+	addrs, err := d.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range addrs {
+		var conn net.Conn
+		conn, err = d.dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+// the permitted addresses of host, ErrBlockedAddress when it only resolves to blocked ones
+func (d *guardedDialer) resolve(ctx context.Context, host string) ([]netip.Addr, error) {
+	addrs := []netip.Addr{}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addrs = append(addrs, addr)
+	} else {
+		if addrs, err = d.resolver.LookupNetIP(ctx, "ip", host); err != nil {
+			return nil, err
+		}
+	}
+
+	permitted := []netip.Addr{}
+	for _, addr := range addrs {
+		if d.allow.allowsAddr(addr.Unmap()) || !IsBlockedAddr(addr) {
+			permitted = append(permitted, addr)
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
+	}
+	if len(permitted) == 0 {
+		return nil, fmt.Errorf("%w: %s resolves to %s", ErrBlockedAddress, host, addrs[0])
+	}
+	return permitted, nil
+}
+
+// checks the target of proxied requests, which the proxy resolves, and trusts the proxy address itself.
+// targets that do not resolve locally are left to the proxy
+func (d *guardedDialer) guardProxy(proxy func(*http.Request) (*url.URL, error)) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		proxyURL, err := proxy(req)
+		if err != nil || proxyURL == nil {
+			return proxyURL, err
+		}
+
+		host := req.URL.Hostname()
+		if !d.allow.allowsHost(host) {
+			var dnsErr *net.DNSError
+			if _, err := d.resolve(req.Context(), host); err != nil && !errors.As(err, &dnsErr) {
+				return nil, err
+			}
+		}
+
+		d.proxies.Store(proxyAddr(proxyURL), struct{}{})
+		return proxyURL, nil
+	}
+}
+
+// the host:port the transport dials for proxyURL
+func proxyAddr(proxyURL *url.URL) string {
+	if port := proxyURL.Port(); port != "" {
+		return proxyURL.Host
+	}
+	port := "80"
+	switch proxyURL.Scheme {
+	case "https":
+		port = "443"
+	case "socks5", "socks5h":
+		port = "1080"
+	}
+	return net.JoinHostPort(proxyURL.Hostname(), port)
+}
@@ -0,0 +1,157 @@
+package outbound
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+)
+
+func TestIsBlockedAddr(t *testing.T) {
+	tests := []struct {
+		addr    string
+		blocked bool
+	}{
+		{addr: "127.0.0.1", blocked: true},
+		{addr: "::1", blocked: true},
+		{addr: "10.1.2.3", blocked: true},
+		{addr: "172.16.0.1", blocked: true},
+		{addr: "192.168.1.1", blocked: true},
+		{addr: "169.254.169.254", blocked: true},
+		{addr: "100.100.100.200", blocked: true},
+		{addr: "fd00:ec2::254", blocked: true},
+		{addr: "fe80::1", blocked: true},
+		{addr: "224.0.0.1", blocked: true},
+		{addr: "ff02::1", blocked: true},
+		{addr: "0.0.0.0", blocked: true},
+		{addr: "::ffff:127.0.0.1", blocked: true},
+		{addr: "64:ff9b::a9fe:a9fe", blocked: true},
+		{addr: "93.184.216.34", blocked: false},
+		{addr: "2606:4700::6810:84e5", blocked: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if blocked := IsBlockedAddr(netip.MustParseAddr(tt.addr)); blocked != tt.blocked {
+				t.Errorf("IsBlockedAddr(%s) = %v, want %v", tt.addr, blocked, tt.blocked)
+			}
+		})
+	}
+}
+
+func TestGuardedTransport(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "internal")
+	}))
+	defer internal.Close()
+	internalURL, _ := url.Parse(internal.URL)
+
+	// reached through the allow-listed localhost name, redirects to the loopback address
+	redirect := httptest.NewServer(http.RedirectHandler(internal.URL, http.StatusFound))
+	defer redirect.Close()
+	redirectURL, _ := url.Parse(redirect.URL)
+
+	tests := []struct {
+		name     string
+		allow    string
+		url      string
+		expected string
+		blocked  bool
+	}{
+		{
+			name:    "loopback address",
+			url:     internal.URL,
+			blocked: true,
+		},
+		{
+			name:    "name resolving to loopback",
+			url:     "http://localhost:" + internalURL.Port(),
+			blocked: true,
+		},
+		{
+			name:     "allow-listed network",
+			allow:    "127.0.0.0/8",
+			url:      internal.URL,
+			expected: "internal",
+		},
+		{
+			name:    "redirect to a blocked address",
+			allow:   "localhost",
+			url:     "http://localhost:" + redirectURL.Port(),
+			blocked: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allow, err := ParseAllowList(tt.allow)
+			if err != nil {
+				t.Fatalf("ParseAllowList() unexpected error: %v", err)
+			}
+			transport, err := NewTransport(Settings{BlockPrivateNetworks: true, AllowList: allow})
+			if err != nil {
+				t.Fatalf("NewTransport() unexpected error: %v", err)
+			}
+
+			resp, err := (&http.Client{Transport: transport}).Get(tt.url)
+			if tt.blocked {
+				if !errors.Is(err, ErrBlockedAddress) {
+					t.Errorf("GET %s error = %v, want %v", tt.url, err, ErrBlockedAddress)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GET %s unexpected error: %v", tt.url, err)
+			}
+			resp.Body.Close()
+		})
+	}
+}
+
+func TestGuardedTransportProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "proxied %s", r.URL.Host)
+	}))
+	defer proxy.Close()
+
+	transport, err := NewTransport(Settings{ProxyURL: proxy.URL, BlockPrivateNetworks: true})
+	if err != nil {
+		t.Fatalf("NewTransport() unexpected error: %v", err)
+	}
+
+	// the loopback proxy is trusted and resolves names we cannot
+	if body := get(t, transport, "http://example.test/"); body != "proxied example.test" {
+		t.Errorf("response = %q, want proxied example.test", body)
+	}
+	if _, err := (&http.Client{Transport: transport}).Get("http://169.254.169.254/latest/meta-data/"); !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("proxied metadata request error = %v, want %v", err, ErrBlockedAddress)
+	}
+}
+
+func TestParseAllowList(t *testing.T) {
+	allow, err := ParseAllowList(" 10.20.0.0/16, 192.168.1.7 ,Intranet.Example.com,.corp.example.com,")
+	if err != nil {
+		t.Fatalf("ParseAllowList() unexpected error: %v", err)
+	}
+
+	for _, addr := range []string{"10.20.3.4", "192.168.1.7", "::ffff:10.20.0.1"} {
+		if !allow.allowsAddr(netip.MustParseAddr(addr).Unmap()) {
+			t.Errorf("allowsAddr(%s) = false, want true", addr)
+		}
+	}
+	if allow.allowsAddr(netip.MustParseAddr("192.168.1.8")) {
+		t.Error("allowsAddr(192.168.1.8) = true, want false")
+	}
+	for host, want := range map[string]bool{"intranet.example.com": true, "wiki.corp.example.com": true, "corp.example.com": true, "example.com": false, "evilcorp.example.com": false} {
+		if allow.allowsHost(host) != want {
+			t.Errorf("allowsHost(%s) = %v, want %v", host, !want, want)
+		}
+	}
+
+	if _, err := ParseAllowList("10.0.0.0/33"); !errors.Is(err, ErrInvalidSettings) {
+		t.Errorf("ParseAllowList() error = %v, want %v", err, ErrInvalidSettings)
+	}
+}
@@ -0,0 +1,165 @@
+package outbound
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"golang.org/x/net/http/httpproxy"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSettings = errors.New("invalid outbound transport settings")
+
+// Settings describes how requests to analyzed sites leave the server
+type Settings struct {
+	// ProxyURL is an http, https, socks5 or socks5h proxy, the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	// environment variables are used when it is empty
+	ProxyURL string
+	// NoProxy is a comma separated list of hosts, domains and CIDR ranges reached without the proxy
+	NoProxy string
+	// CABundlePath is a PEM file with certificates trusted on top of the system roots
+	CABundlePath string
+	// HostOverrides maps host names to the IP addresses dialed for them, TLS still verifies the host name
+	HostOverrides map[string]string
+	// BlockPrivateNetworks refuses connections to loopback, private, link-local, multicast and metadata addresses,
+	// every redirect and every DNS answer is checked again
+	BlockPrivateNetworks bool
+	// AllowList is reachable even when private networks are blocked
+	AllowList AllowList
+}
+
+// NewTransport builds the transport shared by every request to analyzed sites
+func NewTransport(settings Settings) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	proxy, err := proxyFunc(settings.ProxyURL, settings.NoProxy)
+	if err != nil {
+		return nil, err
+	}
+	transport.Proxy = proxy
+
+	if settings.CABundlePath != "" {
+		roots, err := loadCABundle(settings.CABundlePath)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots}
+	}
+
+	overrides, err := normalizeOverrides(settings.HostOverrides)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: dialTimeout, KeepAlive: dialKeepAlive}
+
+	switch {
+	case settings.BlockPrivateNetworks:
+		guarded := &guardedDialer{dialer: dialer, resolver: net.DefaultResolver, allow: settings.AllowList, overrides: overrides}
+		transport.DialContext = guarded.DialContext
+		transport.Proxy = guarded.guardProxy(transport.Proxy)
+	case len(overrides) > 0:
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, port, err := net.SplitHostPort(addr)
+			if err == nil {
+				if ip, ok := overrides[strings.ToLower(host)]; ok {
+					addr = net.JoinHostPort(ip, port)
+				}
+			}
+			return dialer.DialContext(ctx, network, addr)
+		}
+	}
+
+	return transport, nil
+}
+
+// ParseHostOverrides parses a comma separated list of host=ip pairs
+func ParseHostOverrides(s string) (map[string]string, error) {
+	overrides := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		host, ip, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: host override %q is not host=ip", ErrInvalidSettings, pair)
+		}
+		overrides[strings.TrimSpace(host)] = strings.TrimSpace(ip)
+	}
+	return overrides, nil
+}
+
+// same values as http.DefaultTransport
+const (
+	dialTimeout   = 30 * time.Second
+	dialKeepAlive = 30 * time.Second
+)
+
+// proxies every request through proxyURL except the NoProxy hosts, falls back to the environment without proxyURL
+func proxyFunc(proxyURL, noProxy string) (func(*http.Request) (*url.URL, error), error) {
+	if proxyURL == "" {
+		if noProxy == "" {
+			return http.ProxyFromEnvironment, nil
+		}
+		cfg := httpproxy.FromEnvironment()
+		cfg.NoProxy = noProxy
+		return requestProxy(cfg), nil
+	}
+
+	u, err := url.Parse(proxyURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("%w: proxy URL %q", ErrInvalidSettings, proxyURL)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("%w: unsupported proxy scheme %q", ErrInvalidSettings, u.Scheme)
+	}
+
+	return requestProxy(&httpproxy.Config{HTTPProxy: proxyURL, HTTPSProxy: proxyURL, NoProxy: noProxy}), nil
+}
+
+func requestProxy(cfg *httpproxy.Config) func(*http.Request) (*url.URL, error) {
+	proxy := cfg.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}
+}
+
+// the system roots extended with the certificates of the PEM file at path
+func loadCABundle(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading CA bundle: %v", ErrInvalidSettings, err)
+	}
+
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%w: CA bundle %s has no PEM certificates", ErrInvalidSettings, path)
+	}
+	return roots, nil
+}
+
+// lower cases the host names and checks that every override is an IP address
+func normalizeOverrides(overrides map[string]string) (map[string]string, error) {
+	normalized := make(map[string]string, len(overrides))
+	for host, ip := range overrides {
+		addr, err := netip.ParseAddr(ip)
+		if host == "" || err != nil {
+			return nil, fmt.Errorf("%w: host override %s=%s", ErrInvalidSettings, host, ip)
+		}
+		normalized[strings.ToLower(host)] = addr.String()
+	}
+	return normalized, nil
+}
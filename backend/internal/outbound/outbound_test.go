@@ -0,0 +1,147 @@
+package outbound
+
+import (
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewTransportProxy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "direct")
+	}))
+	defer target.Close()
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "proxied %s", r.URL.Host)
+	}))
+	defer proxy.Close()
+
+	targetURL, _ := url.Parse(target.URL)
+
+	tests := []struct {
+		name     string
+		settings Settings
+		url      string
+		expected string
+	}{
+		{
+			name:     "request through the proxy",
+			settings: Settings{ProxyURL: proxy.URL},
+			url:      "http://example.test/page",
+			expected: "proxied example.test",
+		},
+		{
+			name:     "host in NO_PROXY",
+			settings: Settings{ProxyURL: proxy.URL, NoProxy: ".example.test", HostOverrides: map[string]string{"www.example.test": "127.0.0.1"}},
+			url:      "http://www.example.test:" + targetURL.Port() + "/",
+			expected: "direct",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := NewTransport(tt.settings)
+			if err != nil {
+				t.Fatalf("NewTransport() unexpected error: %v", err)
+			}
+			if body := get(t, transport, tt.url); body != tt.expected {
+				t.Errorf("response = %q, want %q", body, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewTransportCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "trusted")
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	untrusted, err := NewTransport(Settings{})
+	if err != nil {
+		t.Fatalf("NewTransport() unexpected error: %v", err)
+	}
+	if _, err := (&http.Client{Transport: untrusted}).Get(server.URL); err == nil {
+		t.Error("request without the CA bundle succeeded, want an unknown authority error")
+	}
+
+	// the test certificate is issued for example.com, the override keeps it as the verified name
+	transport, err := NewTransport(Settings{CABundlePath: bundle, HostOverrides: map[string]string{"Example.com": "127.0.0.1"}})
+	if err != nil {
+		t.Fatalf("NewTransport() unexpected error: %v", err)
+	}
+	if body := get(t, transport, "https://example.com:"+serverURL.Port()+"/"); body != "trusted" {
+		t.Errorf("response = %q, want trusted", body)
+	}
+}
+
+func TestNewTransportInvalidSettings(t *testing.T) {
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		settings Settings
+	}{
+		{name: "unsupported proxy scheme", settings: Settings{ProxyURL: "ftp://proxy.example.com:21"}},
+		{name: "proxy without host", settings: Settings{ProxyURL: "proxy.example.com"}},
+		{name: "missing CA bundle", settings: Settings{CABundlePath: filepath.Join(t.TempDir(), "missing.pem")}},
+		{name: "CA bundle without certificates", settings: Settings{CABundlePath: empty}},
+		{name: "override to a host name", settings: Settings{HostOverrides: map[string]string{"example.com": "localhost"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewTransport(tt.settings); !errors.Is(err, ErrInvalidSettings) {
+				t.Errorf("NewTransport() error = %v, want %v", err, ErrInvalidSettings)
+			}
+		})
+	}
+
+	if _, err := NewTransport(Settings{ProxyURL: "socks5://proxy.example.com:1080"}); err != nil {
+		t.Errorf("NewTransport() with a SOCKS5 proxy unexpected error: %v", err)
+	}
+}
+
+func TestParseHostOverrides(t *testing.T) {
+	overrides, err := ParseHostOverrides(" staging.example.com=10.0.0.5, ,api.example.com = ::1")
+	if err != nil {
+		t.Fatalf("ParseHostOverrides() unexpected error: %v", err)
+	}
+	if len(overrides) != 2 || overrides["staging.example.com"] != "10.0.0.5" || overrides["api.example.com"] != "::1" {
+		t.Errorf("ParseHostOverrides() = %v", overrides)
+	}
+
+	if _, err := ParseHostOverrides("example.com"); !errors.Is(err, ErrInvalidSettings) {
+		t.Errorf("ParseHostOverrides() error = %v, want %v", err, ErrInvalidSettings)
+	}
+}
+
+func get(t *testing.T, transport http.RoundTripper, rawURL string) string {
+	t.Helper()
+
+	resp, err := (&http.Client{Transport: transport}).Get(rawURL)
+	if err != nil {
+		t.Fatalf("GET %s unexpected error: %v", rawURL, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
@@ -0,0 +1,150 @@
+package robots
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"webanalyzer/internal/log"
+)
+
+// MaxRobotsSize is the number of bytes of a robots.txt file that are parsed, as recommended by RFC 9309
+const MaxRobotsSize = 500 * 1024
+
+// Cache fetches and caches the robots.txt rules of each host, keeping at most maxEntries hosts
+type Cache struct {
+	client     *http.Client
+	userAgent  string
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+type entry struct {
+	ready   chan struct{}
+	robots  *Robots
+	expires time.Time
+}
+
+func NewCache(client *http.Client, userAgent string, ttl time.Duration, maxEntries int) *Cache {
+	return &Cache{
+		client:     client,
+		userAgent:  userAgent,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*entry),
+	}
+}
+
+// RobotsURL returns the robots.txt location for the host of u
+func RobotsURL(u *url.URL) string {
+	return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host) + "/robots.txt"
+}
+
+// Get returns the robots.txt rules for the host of u, fetching them on the first use.
+// concurrent callers for the same host share one fetch
+func (c *Cache) Get(ctx context.Context, u *url.URL) *Robots {
+	key := RobotsURL(u)
+
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if ok {
+		select {
+		case <-e.ready:
+			ok = time.Now().Before(e.expires)
+		default:
+		}
+	}
+	if !ok {
+		c.evict(time.Now())
+		e = &entry{ready: make(chan struct{})}
+		c.entries[key] = e
+		c.mu.Unlock()
+
+		e.robots = c.fetch(ctx, key)
+		e.expires = time.Now().Add(c.ttl)
+		if ctx.Err() != nil {
+			// do not keep the fallback rules of an interrupted fetch
+			e.expires = time.Now()
+		}
+		close(e.ready)
+		return e.robots
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-e.ready:
+		return e.robots
+	case <-ctx.Done():
+		return AllowAll
+	}
+}
+
+// makes room for a new entry once the cache is full, dropping the expired entries and,
+// when none expired, the one that expires first. entries still being fetched are kept.
+// the caller holds c.mu
+func (c *Cache) evict(now time.Time) {
+	if len(c.entries) < c.maxEntries {
+		return
+	}
+	oldest := ""
+	for key, e := range c.entries {
+		select {
+		case <-e.ready:
+		default:
+			continue
+		}
+		if !now.Before(e.expires) {
+			delete(c.entries, key)
+			continue
+		}
+		if oldest == "" || e.expires.Before(c.entries[oldest].expires) {
+			oldest = key
+		}
+	}
+	if len(c.entries) >= c.maxEntries && oldest != "" {
+		delete(c.entries, oldest)
+	}
+}
+
+// downloads and parses a robots.txt file.
+// a missing file (4xx) allows everything and a server error (5xx) disallows everything, as required by RFC 9309.
+// network errors allow everything so the actual fetch reports the failure
+func (c *Cache) fetch(ctx context.Context, robotsURL string) *Robots {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return AllowAll
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		log.Logger.Warn("failed to fetch robots.txt", zap.String("url", robotsURL), zap.Error(err))
+		return AllowAll
+	}
+	defer func(Body io.ReadCloser) {
+		Body.Close()
+	}(resp.Body)
+
+	switch {
+	case resp.StatusCode >= 500:
+		return DisallowAll
+	case resp.StatusCode >= 400:
+		return AllowAll
+	case resp.StatusCode != http.StatusOK:
+		return AllowAll
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxRobotsSize))
+	if err != nil {
+		log.Logger.Warn("failed to read robots.txt", zap.String("url", robotsURL), zap.Error(err))
+		return AllowAll
+	}
+	return Parse(body)
+}
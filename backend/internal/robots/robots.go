@@ -0,0 +1,210 @@
+package robots
+
+import (
+	"bufio"
+	"bytes"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Robots holds the parsed rules of a robots.txt file
+type Robots struct {
+	groups   []group
+	Sitemaps []string
+}
+
+type group struct {
+	agents     []string
+	rules      []rule
+	crawlDelay time.Duration
+}
+
+type rule struct {
+	allow   bool
+	pattern string
+}
+
+// AllowAll is the rule set used when a site has no usable robots.txt
+var AllowAll = &Robots{}
+
+// DisallowAll is the rule set used when the robots.txt of a site is unavailable because of a server error
+var DisallowAll = &Robots{groups: []group{{agents: []string{"*"}, rules: []rule{{allow: false, pattern: "/"}}}}}
+
+// Parse parses the content of a robots.txt file.
+// unknown fields and malformed lines are ignored
+func Parse(body []byte) *Robots {
+	r := &Robots{}
+
+	var current *group
+	inAgentLines := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.IndexByte(line, '#'); idx >= 0 {
+			line = line[:idx]
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgentLines {
+				r.groups = append(r.groups, group{})
+				current = &r.groups[len(r.groups)-1]
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			inAgentLines = true
+		case "allow", "disallow":
+			inAgentLines = false
+			if current == nil || value == "" {
+				continue
+			}
+			current.rules = append(current.rules, rule{allow: key == "allow", pattern: value})
+		case "crawl-delay":
+			inAgentLines = false
+			if current == nil {
+				continue
+			}
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		case "sitemap":
+			r.Sitemaps = append(r.Sitemaps, value)
+		default:
+			inAgentLines = false
+		}
+	}
+
+	return r
+}
+
+// Allowed reports whether userAgent may fetch u.
+// the most specific matching rule wins, allow wins a tie
+func (r *Robots) Allowed(userAgent string, u *url.URL) bool {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	allowed := true
+	longest := -1
+	for _, g := range r.matchingGroups(userAgent) {
+		for _, rl := range g.rules {
+			if !matchPattern(rl.pattern, path) {
+				continue
+			}
+			if len(rl.pattern) > longest || (len(rl.pattern) == longest && rl.allow) {
+				longest = len(rl.pattern)
+				allowed = rl.allow
+			}
+		}
+	}
+	return allowed
+}
+
+// CrawlDelay returns the Crawl-delay that applies to userAgent, or zero when none is set
+func (r *Robots) CrawlDelay(userAgent string) time.Duration {
+	var delay time.Duration
+	for _, g := range r.matchingGroups(userAgent) {
+		if g.crawlDelay > delay {
+			delay = g.crawlDelay
+		}
+	}
+	return delay
+}
+
+// returns the groups for the most specific user agent matching userAgent,
+// falling back to the "*" groups
+func (r *Robots) matchingGroups(userAgent string) []group {
+	token := productToken(userAgent)
+
+	var matched, wildcard []group
+	best := 0
+	for _, g := range r.groups {
+		specificity := g.match(token)
+		switch {
+		case specificity < 0:
+			continue
+		case specificity == 0:
+			wildcard = append(wildcard, g)
+		case specificity > best:
+			best = specificity
+			matched = []group{g}
+		case specificity == best:
+			matched = append(matched, g)
+		}
+	}
+
+	if len(matched) > 0 {
+		return matched
+	}
+	return wildcard
+}
+
+// returns the length of the longest user agent of the group matching token,
+// 0 when the group only matches through "*" and -1 when it does not apply
+func (g group) match(token string) int {
+	specificity := -1
+	for _, agent := range g.agents {
+		switch {
+		case agent == "*":
+			if specificity < 0 {
+				specificity = 0
+			}
+		case agent != "" && strings.HasPrefix(token, agent) && len(agent) > specificity:
+			specificity = len(agent)
+		}
+	}
+	return specificity
+}
+
+// the lowercase product token of a User-Agent header, e.g. "webanalyzer" for "WebAnalyzer/1.0 (+info)"
+func productToken(userAgent string) string {
+	token := strings.ToLower(strings.TrimSpace(userAgent))
+	if idx := strings.IndexAny(token, "/ "); idx >= 0 {
+		token = token[:idx]
+	}
+	return token
+}
+
+// matches a robots.txt path pattern supporting the "*" wildcard and the "$" end anchor
+func matchPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = strings.TrimSuffix(pattern, "$")
+	}
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+
+	for i := 1; i < len(parts); i++ {
+		part := parts[i]
+		if i == len(parts)-1 && anchored {
+			return len(path)-pos >= len(part) && strings.HasSuffix(path, part)
+		}
+		idx := strings.Index(path[pos:], part)
+		if idx < 0 {
+			return false
+		}
+		pos += idx + len(part)
+	}
+
+	return !anchored || pos == len(path)
+}
@@ -0,0 +1,188 @@
+package robots
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+	"webanalyzer/internal/log"
+)
+
+const sample = `
+# comment line
+User-agent: *
+Disallow: /private
+Allow: /private/public
+Disallow: /*.pdf$
+Disallow: /search?q=
+Crawl-delay: 2
+
+User-agent: WebAnalyzer
+User-agent: OtherBot
+Disallow: /no-analyzer
+Allow: /no-analyzer/yes
+Crawl-delay: 0.5
+
+User-agent: WebAnalyzer-Images
+Disallow: /
+
+Sitemap: https://example.com/sitemap.xml
+`
+
+func TestAllowed(t *testing.T) {
+	r := Parse([]byte(sample))
+
+	tests := []struct {
+		name      string
+		userAgent string
+		path      string
+		expected  bool
+	}{
+		{"wildcard group disallow", "SomeBot/2.0", "/private/page", false},
+		{"longer allow wins", "SomeBot/2.0", "/private/public/page", true},
+		{"end anchor match", "SomeBot/2.0", "/files/report.pdf", false},
+		{"end anchor no match", "SomeBot/2.0", "/files/report.pdf?download=1", true},
+		{"query string rule", "SomeBot/2.0", "/search?q=test", false},
+		{"unmatched path", "SomeBot/2.0", "/about", true},
+		{"specific group replaces wildcard", "WebAnalyzer/1.0 (+info)", "/private/page", true},
+		{"specific group disallow", "WebAnalyzer/1.0 (+info)", "/no-analyzer/page", false},
+		{"specific group allow", "WebAnalyzer/1.0", "/no-analyzer/yes", true},
+		{"case insensitive agent", "webanalyzer", "/no-analyzer", false},
+		{"most specific agent", "WebAnalyzer-Images/1.0", "/anything", false},
+		{"robots.txt always allowed", "WebAnalyzer-Images/1.0", "/robots.txt", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := url.Parse("https://example.com" + tt.path)
+			if got := r.Allowed(tt.userAgent, u); got != tt.expected {
+				t.Errorf("Allowed(%q, %q) = %v, want %v", tt.userAgent, tt.path, got, tt.expected)
+			}
+		})
+	}
+
+	if got := r.CrawlDelay("SomeBot"); got != 2*time.Second {
+		t.Errorf("CrawlDelay(SomeBot) = %v, want 2s", got)
+	}
+	if got := r.CrawlDelay("WebAnalyzer/1.0"); got != 500*time.Millisecond {
+		t.Errorf("CrawlDelay(WebAnalyzer) = %v, want 500ms", got)
+	}
+	if len(r.Sitemaps) != 1 || r.Sitemaps[0] != "https://example.com/sitemap.xml" {
+		t.Errorf("Sitemaps = %v", r.Sitemaps)
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{"/", "/anything", true},
+		{"/fish", "/fish.html", true},
+		{"/fish", "/Fish.html", false},
+		{"/fish*", "/fishheads", true},
+		{"/*.php", "/folder/filename.php?parameters", true},
+		{"/*.php$", "/filename.php", true},
+		{"/*.php$", "/filename.php/", false},
+		{"/fish*.php", "/fishheads/catfish.php?parameters", true},
+		{"/fish*.php", "/Fish.PHP", false},
+		{"/a$", "/a", true},
+		{"/a$", "/ab", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchPattern(tt.pattern, tt.path); got != tt.expected {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestCache(t *testing.T) {
+	log.Logger = zap.NewNop()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("User-Agent") != "TestBot/1.0" {
+			t.Errorf("robots.txt requested with user agent %q", r.Header.Get("User-Agent"))
+		}
+		fmt.Fprint(w, "User-agent: *\nDisallow: /blocked\n")
+	}))
+	defer server.Close()
+
+	errorServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer errorServer.Close()
+
+	missingServer := httptest.NewServer(http.NotFoundHandler())
+	defer missingServer.Close()
+
+	cache := NewCache(server.Client(), "TestBot/1.0", time.Hour, 10)
+	ctx := context.Background()
+
+	blocked, _ := url.Parse(server.URL + "/blocked")
+	open, _ := url.Parse(server.URL + "/open")
+	if cache.Get(ctx, blocked).Allowed("TestBot", blocked) {
+		t.Error("expected /blocked to be disallowed")
+	}
+	if !cache.Get(ctx, open).Allowed("TestBot", open) {
+		t.Error("expected /open to be allowed")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("robots.txt fetched %d times, want 1", got)
+	}
+
+	unavailable, _ := url.Parse(errorServer.URL + "/page")
+	if cache.Get(ctx, unavailable).Allowed("TestBot", unavailable) {
+		t.Error("expected a 5xx robots.txt to disallow everything")
+	}
+
+	missing, _ := url.Parse(missingServer.URL + "/page")
+	if !cache.Get(ctx, missing).Allowed("TestBot", missing) {
+		t.Error("expected a missing robots.txt to allow everything")
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	log.Logger = zap.NewNop()
+
+	var requests atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		fmt.Fprint(w, "User-agent: *\nDisallow: /blocked\n")
+	})
+	var hosts []*url.URL
+	for i := 0; i < 3; i++ {
+		server := httptest.NewServer(handler)
+		defer server.Close()
+		u, _ := url.Parse(server.URL + "/page")
+		hosts = append(hosts, u)
+	}
+
+	cache := NewCache(http.DefaultClient, "TestBot/1.0", time.Hour, 2)
+	ctx := context.Background()
+	for _, u := range hosts {
+		cache.Get(ctx, u)
+		// distinct expiry times, so the first host is the one that expires first
+		time.Sleep(time.Millisecond)
+	}
+
+	if len(cache.entries) != 2 {
+		t.Errorf("cache holds %d entries, want 2", len(cache.entries))
+	}
+	if _, ok := cache.entries[RobotsURL(hosts[0])]; ok {
+		t.Error("expected the entry that expires first to be evicted")
+	}
+
+	cache.Get(ctx, hosts[2])
+	if got := requests.Load(); got != 3 {
+		t.Errorf("robots.txt fetched %d times, want 3", got)
+	}
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"strings"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/util/analyzer"
+)
+
+// accessibility rules reported by auditAccessibility
+const (
+	ruleImageAlt     = "image-alt"
+	ruleFormLabel    = "form-label"
+	ruleHTMLLang     = "html-lang"
+	ruleButtonName   = "button-name"
+	ruleLinkName     = "link-name"
+	ruleHeadingOrder = "heading-order"
+	ruleDuplicateID  = "duplicate-id"
+	ruleLandmarkMain = "landmark-main"
+)
+
+// landmark regions and the elements or roles that provide them
+var landmarks = []struct {
+	name     string
+	elements []string
+}{
+	{"main", []string{"main"}},
+	{"navigation", []string{"nav"}},
+	{"banner", []string{"header"}},
+	{"contentinfo", []string{"footer"}},
+}
+
+// input types that do not need a label
+var unlabeledInputTypes = map[string]bool{"hidden": true, "submit": true, "reset": true, "button": true, "image": true}
+
+// runs basic WCAG checks on the document: image alternatives, form labels, document language,
+// accessible names of buttons and links, heading order, duplicate ids and landmark regions
+func auditAccessibility(root *html.Node) *model.Accessibility {
+	audit := &model.Accessibility{
+		Issues:           []model.AccessibilityIssue{},
+		IssueCounts:      make(map[string]int),
+		MissingLandmarks: []string{},
+	}
+	// counted up front, a selector may not start at an id that is repeated further down the document
+	idCounts := analyzer.CountIDs(root)
+	report := func(rule, message string, node *html.Node) {
+		audit.Issues = append(audit.Issues, model.AccessibilityIssue{Rule: rule, Message: message, Selector: analyzer.CSSSelector(node, idCounts)})
+		audit.IssueCounts[rule]++
+	}
+
+	labelled := labelledIDs(root)
+	ids := make(map[string]int)
+	foundLandmarks := make(map[string]bool)
+	lastHeading := 0
+
+	var visitNode func(*html.Node)
+	visitNode = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Namespace == "" {
+			if id := analyzer.GetAttr(node, "id"); id != "" {
+				ids[id]++
+				if ids[id] == 2 {
+					report(ruleDuplicateID, fmt.Sprintf("id %q is used more than once", id), node)
+				}
+			}
+
+			if role := strings.ToLower(analyzer.GetAttr(node, "role")); role != "" {
+				foundLandmarks[role] = true
+			}
+			for _, landmark := range landmarks {
+				if containsToken(landmark.elements, node.Data) {
+					foundLandmarks[landmark.name] = true
+				}
+			}
+
+			switch node.Data {
+			case "html":
+				if strings.TrimSpace(analyzer.GetAttr(node, "lang")) == "" {
+					report(ruleHTMLLang, "<html> element has no lang attribute", node)
+				}
+			case "img":
+				if !analyzer.HasAttr(node, "alt") && !isPresentational(node) {
+					report(ruleImageAlt, "image has no alt attribute", node)
+				}
+			case "input", "select", "textarea":
+				inputType := strings.ToLower(analyzer.GetAttr(node, "type"))
+				if node.Data == "input" && unlabeledInputTypes[inputType] {
+					// submit and reset buttons get a default name from the browser
+					if (inputType == "button" || inputType == "image") && !hasAccessibleName(node) {
+						report(ruleButtonName, "button has no accessible name", node)
+					}
+					break
+				}
+				if !hasLabel(node, labelled) {
+					report(ruleFormLabel, "form field has no associated label", node)
+				}
+			case "button":
+				if !hasAccessibleName(node) {
+					report(ruleButtonName, "button has no accessible name", node)
+				}
+			case "a":
+				if analyzer.HasAttr(node, "href") && !hasAccessibleName(node) {
+					report(ruleLinkName, "link has no accessible name", node)
+				}
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				level := headingLevel(node)
+				if lastHeading > 0 && level > lastHeading+1 {
+					report(ruleHeadingOrder, fmt.Sprintf("heading level skipped from h%d to h%d", lastHeading, level), node)
+				}
+				lastHeading = level
+			}
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			visitNode(child)
+		}
+	}
+	visitNode(root)
+
+	for _, landmark := range landmarks {
+		if foundLandmarks[landmark.name] {
+			continue
+		}
+		audit.MissingLandmarks = append(audit.MissingLandmarks, landmark.name)
+	}
+	if !foundLandmarks["main"] {
+		audit.Issues = append(audit.Issues, model.AccessibilityIssue{Rule: ruleLandmarkMain, Message: "page has no main landmark", Selector: "body"})
+		audit.IssueCounts[ruleLandmarkMain]++
+	}
+
+	return audit
+}
+
+// collects the ids referenced by <label for="...">
+func labelledIDs(root *html.Node) map[string]bool {
+	ids := make(map[string]bool)
+	var visitNode func(*html.Node)
+	visitNode = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Data == "label" {
+			if id := analyzer.GetAttr(node, "for"); id != "" {
+				ids[id] = true
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			visitNode(child)
+		}
+	}
+	visitNode(root)
+	return ids
+}
+
+// reports whether a form field is labelled by a <label>, an enclosing <label> or ARIA attributes
+func hasLabel(node *html.Node, labelled map[string]bool) bool {
+	if hasARIAName(node) {
+		return true
+	}
+	if id := analyzer.GetAttr(node, "id"); id != "" && labelled[id] {
+		return true
+	}
+	for p := node.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.Data == "label" {
+			return true
+		}
+	}
+	return false
+}
+
+// reports whether an element has a non empty accessible name from its content or attributes
+func hasAccessibleName(node *html.Node) bool {
+	if hasARIAName(node) {
+		return true
+	}
+	if node.Data == "input" {
+		return strings.TrimSpace(analyzer.GetAttr(node, "value")) != "" || strings.TrimSpace(analyzer.GetAttr(node, "alt")) != ""
+	}
+	if strings.TrimSpace(analyzer.ExtractInnerText(node)) != "" {
+		return true
+	}
+
+	var found bool
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if found {
+			return
+		}
+		if n.Type == html.ElementNode && n.Data == "img" && strings.TrimSpace(analyzer.GetAttr(n, "alt")) != "" {
+			found = true
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	return found
+}
+
+func hasARIAName(node *html.Node) bool {
+	return strings.TrimSpace(analyzer.GetAttr(node, "aria-label")) != "" ||
+		strings.TrimSpace(analyzer.GetAttr(node, "aria-labelledby")) != "" ||
+		strings.TrimSpace(analyzer.GetAttr(node, "title")) != ""
+}
+
+func isPresentational(node *html.Node) bool {
+	role := strings.ToLower(analyzer.GetAttr(node, "role"))
+	return role == "presentation" || role == "none" || strings.EqualFold(analyzer.GetAttr(node, "aria-hidden"), "true")
+}
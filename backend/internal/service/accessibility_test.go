@@ -0,0 +1,104 @@
+package service
+
+import (
+	"golang.org/x/net/html"
+	"reflect"
+	"strings"
+	"testing"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/util/analyzer"
+)
+
+func TestAuditAccessibility(t *testing.T) {
+	node, err := html.Parse(strings.NewReader(`<html><body>
+<header><nav><a href="/"><img src="logo.png" alt="Home"></a><a href="/empty"></a></nav></header>
+<main id="content">
+	<h1>Title</h1>
+	<h3>Skipped</h3>
+	<img src="decorative.png" alt="">
+	<img src="missing.png">
+	<img src="hidden.png" role="presentation">
+	<form>
+		<label for="email">Email</label><input id="email" type="email">
+		<label>Name <input type="text" name="name"></label>
+		<input type="text" name="search" aria-label="Search">
+		<input type="text" name="unlabelled">
+		<input type="hidden" name="token">
+		<input type="submit"><input type="button">
+		<select name="choice"></select>
+		<button aria-label="Close"></button>
+		<button><span></span></button>
+	</form>
+	<p id="dup">one</p><p id="dup">two</p>
+</main>
+</body></html>`))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	audit := auditAccessibility(node)
+
+	expected := []model.AccessibilityIssue{
+		{Rule: ruleHTMLLang, Message: "<html> element has no lang attribute", Selector: "html"},
+		{Rule: ruleLinkName, Message: "link has no accessible name", Selector: "html > body > header > nav > a:nth-of-type(2)"},
+		{Rule: ruleHeadingOrder, Message: "heading level skipped from h1 to h3", Selector: "main#content > h3"},
+		{Rule: ruleImageAlt, Message: "image has no alt attribute", Selector: "main#content > img:nth-of-type(2)"},
+		{Rule: ruleFormLabel, Message: "form field has no associated label", Selector: "main#content > form > input:nth-of-type(3)"},
+		{Rule: ruleButtonName, Message: "button has no accessible name", Selector: "main#content > form > input:nth-of-type(6)"},
+		{Rule: ruleFormLabel, Message: "form field has no associated label", Selector: "main#content > form > select"},
+		{Rule: ruleButtonName, Message: "button has no accessible name", Selector: "main#content > form > button:nth-of-type(2)"},
+		{Rule: ruleDuplicateID, Message: `id "dup" is used more than once`, Selector: "main#content > p:nth-of-type(2)"},
+	}
+	if !reflect.DeepEqual(audit.Issues, expected) {
+		t.Errorf("auditAccessibility() issues =\n%+v\nwant\n%+v", audit.Issues, expected)
+	}
+	if !reflect.DeepEqual(audit.MissingLandmarks, []string{"contentinfo"}) {
+		t.Errorf("missing landmarks = %v, want [contentinfo]", audit.MissingLandmarks)
+	}
+	if audit.IssueCounts[ruleFormLabel] != 2 {
+		t.Errorf("form label issue count = %d, want 2", audit.IssueCounts[ruleFormLabel])
+	}
+
+	bare, _ := html.Parse(strings.NewReader(`<html lang="en"><body><div role="main"><footer></footer></div></body></html>`))
+	audit = auditAccessibility(bare)
+	if len(audit.Issues) != 0 || !reflect.DeepEqual(audit.MissingLandmarks, []string{"navigation", "banner"}) {
+		t.Errorf("issues = %+v, missing landmarks = %v", audit.Issues, audit.MissingLandmarks)
+	}
+}
+
+func TestCSSSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{name: "nth-of-type path", html: `<ul><li>a</li><li><a href="#">b</a></li></ul>`, expected: "html > body > ul > li:nth-of-type(2) > a"},
+		{name: "unique ancestor id", html: `<div id="main"><p>a</p><p><a href="#">b</a></p></div>`, expected: "div#main > p:nth-of-type(2) > a"},
+		{name: "duplicated id", html: `<p id="dup">a</p><p id="dup"><a href="#">b</a></p>`, expected: "html > body > p:nth-of-type(2) > a"},
+		{name: "id with special characters", html: `<div id="a.b:c d"><a href="#">b</a></div>`, expected: `div#a\.b\:c\ d > a`},
+		{name: "id starting with a digit", html: `<div id="1st"><a href="#">b</a></div>`, expected: `div#\31 st > a`},
+		{name: "id starting with a dash and a digit", html: `<div id="-2"><a href="#">b</a></div>`, expected: `div#-\32  > a`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, _ := html.Parse(strings.NewReader(`<html><body>` + tt.html + `</body></html>`))
+
+			var link *html.Node
+			var find func(*html.Node)
+			find = func(n *html.Node) {
+				if n.Type == html.ElementNode && n.Data == "a" {
+					link = n
+				}
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					find(c)
+				}
+			}
+			find(node)
+
+			if got := analyzer.CSSSelector(link, analyzer.CountIDs(node)); got != tt.expected {
+				t.Errorf("CSSSelector() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
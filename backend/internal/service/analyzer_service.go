@@ -2,87 +2,286 @@ package service
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"go.uber.org/zap"
 	"golang.org/x/net/html"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 	"webanalyzer/internal/log"
 	"webanalyzer/internal/model"
 	"webanalyzer/internal/util/analyzer"
 )
 
+// EventFunc receives the events emitted during an analysis.
+// it is never called concurrently
+type EventFunc func(event model.AnalysisEvent)
+
 // AnalyzePage analyzes the HTML content of a webpage at the given target URL.
 // detect the HTML version, extract the page title, count of different headers, count internal, external, and inaccessible links, and has login form in the page
-func AnalyzePage(targetURL string) (*model.WebpageAnalysis, error) {
+// When opts.IncludeLinks is set, the per-link check results are returned as well
+// When opts.CheckResources is set, every subresource is checked and sized for the page weight
+func AnalyzePage(ctx context.Context, targetURL string, opts model.AnalyzeOptions) (*model.WebpageAnalysis, error) {
+	return AnalyzePageWithEvents(ctx, targetURL, opts, nil)
+}
+
+// AnalyzePageWithEvents works like AnalyzePage and emits an event for every stage,
+// extracted value and link check result as soon as it is available
+func AnalyzePageWithEvents(ctx context.Context, targetURL string, opts model.AnalyzeOptions, onEvent EventFunc) (*model.WebpageAnalysis, error) {
 	page := &model.WebpageAnalysis{}
+	emit, emitStage := newEmitter(page, onEvent)
 
 	baseURL, err := url.Parse(targetURL)
 	if err != nil {
 		return page, err
 	}
+	if err := ValidateAnalyzeOptions(opts); err != nil {
+		return page, err
+	}
 
-	root, rawHTML, err := fetchHTML(targetURL)
+	page.Robots = checkRobots(ctx, baseURL, opts)
+	if !page.Robots.Allowed && !opts.IgnoreRobots {
+		emit(model.AnalysisEvent{Type: model.EventError, Data: ErrRobotsDisallowed.Error()})
+		return page, ErrRobotsDisallowed
+	}
+
+	emitStage(model.JobFetching)
+	fetched, err := fetchHTML(ctx, targetURL, opts)
 	if err != nil {
+		if info, tlsErr := inspectRejectedTLS(err, time.Now()); info != nil {
+			page.Security = &model.Security{MixedContent: []model.MixedContentItem{}, TLS: info}
+			err = tlsErr
+		}
+		emit(model.AnalysisEvent{Type: model.EventError, Data: err.Error()})
 		return page, err
 	}
+	// links and resources are resolved against the URL the page was finally served from
+	baseURL = fetched.finalURL
+	page.Redirects = fetched.redirects
+	page.Content = fetched.content
+	page.Encoding = fetched.encoding
+	emit(model.AnalysisEvent{Type: model.EventFetched, Data: model.FetchInfo{
+		URL:           targetURL,
+		FinalURL:      baseURL.String(),
+		ContentLength: len(fetched.rawHTML),
+		Truncated:     fetched.content.Truncated,
+		Encoding:      fetched.encoding.Detected,
+	}})
+
+	return analyzeDocument(ctx, page, fetched, baseURL, opts, true, emit, emitStage)
+}
+
+// serializes the events of an analysis, stage events carry a snapshot of page
+func newEmitter(page *model.WebpageAnalysis, onEvent EventFunc) (EventFunc, func(model.JobState)) {
+	var emitMu sync.Mutex
+	emit := func(event model.AnalysisEvent) {
+		if onEvent == nil {
+			return
+		}
+		emitMu.Lock()
+		defer emitMu.Unlock()
+		onEvent(event)
+	}
+	emitStage := func(stage model.JobState) {
+		snapshot := *page
+		emit(model.AnalysisEvent{Type: model.EventStage, Stage: stage, Data: &snapshot})
+	}
+	return emit, emitStage
+}
+
+// runs every extractor on the parsed document, links and resources are resolved against baseURL.
+// without checkLinks no link or social image is requested, the links are reported as skipped
+func analyzeDocument(ctx context.Context, page *model.WebpageAnalysis, doc *fetchedPage, baseURL *url.URL, opts model.AnalyzeOptions, checkLinks bool, emit EventFunc, emitStage func(model.JobState)) (*model.WebpageAnalysis, error) {
+	root := doc.root
+	extracted := extractLinks(root)
+	resources := extractResources(root, baseURL)
+	// the link, social image and resource checks share one limiter, so together they honor the Crawl-delay of the host.
+	// a document without response headers was supplied by the client rather than fetched
+	var check *linkCheck
+	if checkLinks || opts.CheckResources {
+		check = &linkCheck{hosts: newLinkCheckLimiter(ctx, baseURL, opts, doc.header != nil), scope: doc.requestURL}
+	}
+	// link events flow from here on, the stage comes first and its snapshot is taken before the extractors write to page
+	emitStage(model.JobCheckingLinks)
+	var links []model.LinkDetail
+	linksDone := make(chan struct{})
+	go func() {
+		defer close(linksDone)
+		linkCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		if !checkLinks {
+			links = skipLinks(extracted, baseURL)
+			return
+		}
+		var linkMu sync.Mutex
+		checked := 0
+		links = analyzeLinks(linkCtx, check, extracted, baseURL, opts, func(link model.LinkDetail) {
+			linkMu.Lock()
+			defer linkMu.Unlock()
+			checked++
+			emit(model.AnalysisEvent{Type: model.EventLink, Data: model.LinkProgress{Checked: checked, Total: len(extracted), Link: link}})
+		})
+	}()
 
 	var wg sync.WaitGroup
-	wg.Add(5)
+	wg.Add(10)
 
 	go func() {
 		defer wg.Done()
-		page.HTMLVersion = detectHTMLVersion(rawHTML)
+		page.Doctype = parseDoctype(doc.rawHTML, doc.contentType)
+		page.HTMLVersion = page.Doctype.Version
+		emit(model.AnalysisEvent{Type: model.EventHTMLVersion, Data: page.HTMLVersion})
+		emit(model.AnalysisEvent{Type: model.EventDoctype, Data: page.Doctype})
 	}()
 
 	go func() {
 		defer wg.Done()
 		page.PageTitle = extractTitle(root)
+		emit(model.AnalysisEvent{Type: model.EventTitle, Data: page.PageTitle})
 	}()
 
 	go func() {
 		defer wg.Done()
 		page.HeadingCounts = extractHeadings(root)
+		emit(model.AnalysisEvent{Type: model.EventHeadings, Data: page.HeadingCounts})
+		page.HeadingOutline = extractHeadingOutline(root)
+		emit(model.AnalysisEvent{Type: model.EventHeadingOutline, Data: page.HeadingOutline})
 	}()
 
-	var internal, external, inaccessible int
 	go func() {
 		defer wg.Done()
-		links := extractLinks(root)
-		internal, external, inaccessible = analyzeLinks(links, baseURL)
+		page.HasLoginForm = hasLoginForm(root)
+		emit(model.AnalysisEvent{Type: model.EventLoginForm, Data: page.HasLoginForm})
 	}()
 
 	go func() {
 		defer wg.Done()
-		page.HasLoginForm = hasLoginForm(root)
+		page.SEO = extractSEO(root, baseURL)
+		emit(model.AnalysisEvent{Type: model.EventSEO, Data: page.SEO})
+	}()
+
+	go func() {
+		defer wg.Done()
+		social := extractSocialMetadata(root, baseURL)
+		// same budget as the links, same-host images wait their turn behind the Crawl-delay
+		imageCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		if checkLinks {
+			checkSocialImages(imageCtx, check, social, baseURL, opts)
+		}
+		page.Social = social
+		emit(model.AnalysisEvent{Type: model.EventSocial, Data: page.Social})
+	}()
+
+	go func() {
+		defer wg.Done()
+		page.StructuredData = extractStructuredData(root)
+		emit(model.AnalysisEvent{Type: model.EventStructuredData, Data: page.StructuredData})
+	}()
+
+	go func() {
+		defer wg.Done()
+		page.Accessibility = auditAccessibility(root)
+		emit(model.AnalysisEvent{Type: model.EventAccessibility, Data: page.Accessibility})
+	}()
+
+	go func() {
+		defer wg.Done()
+		if opts.CheckResources {
+			resourceCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			defer cancel()
+			checkResources(resourceCtx, check, resources, baseURL, opts)
+		}
+		page.Resources = summarizeResources(resources, len(doc.rawHTML), opts.CheckResources)
+		emit(model.AnalysisEvent{Type: model.EventResources, Data: page.Resources})
+	}()
+
+	go func() {
+		defer wg.Done()
+		page.Security = &model.Security{
+			MixedContent: detectMixedContent(root, resources, baseURL),
+			TLS:          inspectTLS(doc.tls, baseURL.Hostname(), time.Now()),
+		}
+		// client supplied documents come without response headers
+		if doc.header != nil {
+			page.Security.Headers = auditSecurityHeaders(doc.header, baseURL)
+		}
+		emit(model.AnalysisEvent{Type: model.EventSecurity, Data: page.Security})
 	}()
 
 	wg.Wait()
+	<-linksDone
 
-	page.InternalLinkCount = internal
-	page.ExternalLinkCount = external
-	page.InaccessibleLinkCount = inaccessible
+	if err := ctx.Err(); err != nil {
+		emit(model.AnalysisEvent{Type: model.EventError, Data: err.Error()})
+		return page, err
+	}
+
+	page.InternalLinkCount, page.ExternalLinkCount, page.InaccessibleLinkCount = countLinks(links)
+	if opts.IncludeLinks {
+		page.Links = links
+	}
+	emit(model.AnalysisEvent{Type: model.EventSummary, Data: page})
 
 	return page, nil
 }
 
-// retrieves and parses the HTML content from the given URL
-func fetchHTML(targetURL string) (*html.Node, string, error) {
+// fetchedPage is the parsed page together with the parts of the response the analyzers need
+type fetchedPage struct {
+	root        *html.Node
+	rawHTML     string
+	contentType string
+	header      http.Header
+	tls         *tls.ConnectionState
+	requestURL  *url.URL
+	finalURL    *url.URL
+	redirects   *model.RedirectChain
+	content     *model.Content
+	encoding    *model.Encoding
+}
+
+// retrieves and parses the HTML content from the given URL, sending the custom user agent, headers and cookies of opts
+func fetchHTML(ctx context.Context, targetURL string, opts model.AnalyzeOptions) (*fetchedPage, error) {
+	redirects := newRedirectRecorder(targetURL, opts)
 	client := &http.Client{
-		Timeout: 30 * time.Second,
+		Timeout:       30 * time.Second,
+		Transport:     currentTransport(),
+		CheckRedirect: redirects.checkRedirect,
 	}
 
-	resp, err := client.Get(targetURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, targetURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	applyRequestOptions(req, req.URL, opts)
+	// decoded by readBody, which keeps an eye on the decompressed size
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+	}
+	if opts.UserAgent != "" || len(opts.Headers) > 0 || len(opts.Cookies) > 0 {
+		// header and cookie values are redacted by their JSON encoding
+		log.Logger.Debug("sending custom request options",
+			zap.String("url", targetURL),
+			zap.String("user_agent", opts.UserAgent),
+			zap.Any("headers", opts.Headers),
+			zap.Any("cookies", opts.Cookies),
+		)
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
 		log.Logger.Error("failed to fetch URL",
 			zap.String("url", targetURL),
 			zap.Error(err),
 		)
-		return nil, "", fmt.Errorf("failed to fetch URL: %w", err)
+		return nil, fmt.Errorf("failed to fetch URL: %w", err)
 	}
 	defer func() {
 		if cerr := resp.Body.Close(); cerr != nil {
@@ -95,19 +294,38 @@ func fetchHTML(targetURL string) (*html.Node, string, error) {
 			zap.String("url", targetURL),
 			zap.Int("status_code", resp.StatusCode),
 		)
-		return nil, "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	limits := currentFetchLimits()
+	content, err := checkContentType(resp, limits)
+	if err != nil {
+		log.Logger.Warn("refusing non-HTML content",
+			zap.String("url", targetURL),
+			zap.String("content_type", content.ContentType),
+		)
+		return nil, err
+	}
+
+	body, err := readBody(resp, content, limits.MaxBodyBytes)
 	if err != nil {
 		log.Logger.Warn("failed to read response body",
 			zap.String("url", targetURL),
 			zap.Error(err),
 		)
-		return nil, "", fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	sniffContentType(content, body)
+	if content.Truncated {
+		log.Logger.Warn("response body truncated",
+			zap.String("url", targetURL),
+			zap.Int64("transfer_bytes", content.TransferBytes),
+			zap.Int("body_bytes", content.BodyBytes),
+		)
 	}
 
-	rawHTML := string(body)
+	decoded, encoding := decodeBody(body, content.ContentType)
+	rawHTML := string(decoded)
 
 	root, err := html.Parse(strings.NewReader(rawHTML))
 	if err != nil {
@@ -115,53 +333,28 @@ func fetchHTML(targetURL string) (*html.Node, string, error) {
 			zap.String("url", targetURL),
 			zap.Error(err),
 		)
-		return nil, "", fmt.Errorf("failed to parse HTML: %w", err)
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
 	}
 
 	log.Logger.Info("successfully fetched and parsed HTML",
 		zap.String("url", targetURL),
+		zap.String("final_url", resp.Request.URL.String()),
 		zap.Int("content_length", len(rawHTML)),
 		zap.Int("status_code", resp.StatusCode),
 	)
 
-	return root, rawHTML, nil
-}
-
-// analyze HTML version
-func detectHTMLVersion(rawHTML string) string {
-	if rawHTML == "" {
-		return "No response (possibly blocked or restricted site)"
-	}
-
-	docStart := rawHTML
-	if len(rawHTML) > 2000 {
-		docStart = rawHTML[:2000]
-	}
-
-	docStart = strings.ToLower(docStart)
-
-	switch {
-	case strings.Contains(docStart, "<!doctype html>"):
-		return "HTML5"
-
-	case strings.Contains(docStart, "xhtml 1.0 strict"):
-		return "XHTML 1.0 Strict"
-	case strings.Contains(docStart, "xhtml 1.0 transitional"):
-		return "XHTML 1.0 Transitional"
-	case strings.Contains(docStart, "xhtml 1.0 frameset"):
-		return "XHTML 1.0 Frameset"
-	case strings.Contains(docStart, "xhtml 1.1"):
-		return "XHTML 1.1"
-	case strings.Contains(docStart, "html 4.01 strict"):
-		return "HTML 4.01 Strict"
-	case strings.Contains(docStart, "html 4.01 transitional"):
-		return "HTML 4.01 Transitional"
-	case strings.Contains(docStart, "html 4.01 frameset"):
-		return "HTML 4.01 Frameset"
-
-	default:
-		return "Unknown (possibly HTML5 without explicit DOCTYPE)"
-	}
+	return &fetchedPage{
+		root:        root,
+		rawHTML:     rawHTML,
+		contentType: resp.Header.Get("Content-Type"),
+		header:      resp.Header,
+		tls:         resp.TLS,
+		requestURL:  req.URL,
+		finalURL:    resp.Request.URL,
+		redirects:   redirects.chain(resp.Request.URL),
+		content:     content,
+		encoding:    encoding,
+	}, nil
 }
 
 // fetch the title from the page
@@ -211,16 +404,98 @@ func extractHeadings(root *html.Node) model.HeadingCounts {
 	return counts
 }
 
+// builds the nested heading outline of the document and reports missing or multiple h1s,
+// skipped heading levels and empty headings
+func extractHeadingOutline(root *html.Node) *model.HeadingOutline {
+	outline := &model.HeadingOutline{
+		Headings: []*model.HeadingNode{},
+		Issues:   []model.HeadingIssue{},
+	}
+
+	var stack []*model.HeadingNode
+	previousLevel, h1Count := 0, 0
+
+	var visitNode func(*html.Node)
+	visitNode = func(node *html.Node) {
+		if level := headingLevel(node); level > 0 {
+			heading := &model.HeadingNode{
+				Level: level,
+				Text:  strings.Join(strings.Fields(analyzer.ExtractInnerText(node)), " "),
+			}
+
+			if level == 1 {
+				h1Count++
+			}
+			if heading.Text == "" {
+				outline.Issues = append(outline.Issues, model.HeadingIssue{
+					Type:    "empty_heading",
+					Message: fmt.Sprintf("h%d has no text", level),
+					Level:   level,
+				})
+			}
+			if previousLevel > 0 && level > previousLevel+1 {
+				outline.Issues = append(outline.Issues, model.HeadingIssue{
+					Type:    "level_jump",
+					Message: fmt.Sprintf("heading level jumps from h%d to h%d", previousLevel, level),
+					Level:   level,
+					Text:    heading.Text,
+				})
+			}
+			previousLevel = level
+
+			for len(stack) > 0 && stack[len(stack)-1].Level >= level {
+				stack = stack[:len(stack)-1]
+			}
+			if len(stack) == 0 {
+				outline.Headings = append(outline.Headings, heading)
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, heading)
+			}
+			stack = append(stack, heading)
+			return
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			visitNode(child)
+		}
+	}
+	visitNode(root)
+
+	switch {
+	case h1Count == 0:
+		outline.Issues = append(outline.Issues, model.HeadingIssue{Type: "missing_h1", Message: "page has no h1"})
+	case h1Count > 1:
+		outline.Issues = append(outline.Issues, model.HeadingIssue{Type: "multiple_h1", Message: fmt.Sprintf("page has %d h1 elements", h1Count), Level: 1})
+	}
+
+	return outline
+}
+
+// returns 1-6 for h1-h6 elements and 0 for every other node
+func headingLevel(node *html.Node) int {
+	if node.Type != html.ElementNode || len(node.Data) != 2 || node.Data[0] != 'h' {
+		return 0
+	}
+	if level := int(node.Data[1] - '0'); level >= 1 && level <= 6 {
+		return level
+	}
+	return 0
+}
+
 // extract the links in the html
-func extractLinks(root *html.Node) []string {
-	var links []string
+func extractLinks(root *html.Node) []analyzer.Link {
+	var links []analyzer.Link
 
 	var visitNode func(*html.Node)
 	visitNode = func(node *html.Node) {
 		if analyzer.IsLinkTag(node) {
 			href := analyzer.GetHrefValue(node)
 			if href != "" {
-				links = append(links, href)
+				links = append(links, analyzer.Link{
+					Href: href,
+					Text: strings.Join(strings.Fields(analyzer.ExtractInnerText(node)), " "),
+				})
 			}
 		}
 
@@ -251,67 +526,124 @@ func isInternalLink(link string, baseURL *url.URL) bool {
 // checks if a given link is accessible by attempting to send a HEAD request
 // If the HEAD request fails, it sends a GET request as a fallback
 // The function checks whether the link has an acceptable URL scheme, resolves relative links, and handles redirects
-func checkLinkAccessibility(ctx context.Context, link string, baseURL *url.URL) bool {
+// Links disallowed by robots.txt are skipped unless opts.IgnoreRobots is set
+// Links to the analyzed host are requested with the custom user agent, headers and cookies of opts,
+// spaced out by check (if not nil) so the Crawl-delay of the host is honored
+func checkLinkAccessibility(ctx context.Context, check *linkCheck, link string, baseURL *url.URL, opts model.AnalyzeOptions) analyzer.LinkResult {
 	parsedLink, err := url.Parse(link)
 	if err != nil {
-		return false
+		return analyzer.LinkResult{ErrorClass: analyzer.LinkErrorInvalidURL}
 	}
 
 	resolvedLink := baseURL.ResolveReference(parsedLink)
 
 	scheme := strings.ToLower(resolvedLink.Scheme)
 	if scheme == "" || scheme == "mailto" || scheme == "tel" || scheme == "javascript" {
-		return true
+		return analyzer.LinkResult{IsAccessible: true, Skipped: true}
 	}
 
 	if scheme != "http" && scheme != "https" {
-		return true
+		return analyzer.LinkResult{IsAccessible: true, Skipped: true}
+	}
+
+	if !linkAllowedByRobots(ctx, resolvedLink, opts) {
+		return analyzer.LinkResult{IsAccessible: true, Skipped: true, ErrorClass: analyzer.LinkErrorRobotsDisallowed}
+	}
+	scope := baseURL
+	if check != nil {
+		scope = check.scope
+	}
+	if check != nil && strings.EqualFold(resolvedLink.Host, baseURL.Host) {
+		if err := check.hosts.Wait(ctx, resolvedLink.Host); err != nil {
+			return analyzer.LinkResult{IsAccessible: true, Skipped: true, ErrorClass: analyzer.LinkErrorCrawlDelay}
+		}
 	}
 
 	client := &http.Client{
-		Timeout: analyzer.LinkCheckTimeout,
+		Timeout:   analyzer.LinkCheckTimeout,
+		Transport: currentTransport(),
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			if len(via) >= 3 {
 				return http.ErrUseLastResponse
 			}
+			scopeRedirect(req, scope, opts)
 			return nil
 		},
 	}
 
+	start := time.Now()
 	req, err := http.NewRequestWithContext(ctx, http.MethodHead, resolvedLink.String(), nil)
 	if err != nil {
-		return false
+		return analyzer.LinkResult{ErrorClass: analyzer.LinkErrorInvalidURL}
 	}
+	applyRequestOptions(req, scope, opts)
 
 	resp, err := client.Do(req)
 	if err != nil {
 		req, err = http.NewRequestWithContext(ctx, http.MethodGet, resolvedLink.String(), nil)
 		if err != nil {
-			return false
+			return analyzer.LinkResult{ErrorClass: analyzer.LinkErrorInvalidURL, Latency: time.Since(start)}
 		}
+		applyRequestOptions(req, scope, opts)
 		resp, err = client.Do(req)
 		if err != nil {
-			return false
+			return analyzer.LinkResult{ErrorClass: classifyLinkError(err), Latency: time.Since(start)}
 		}
 	}
 	defer func(Body io.ReadCloser) {
 		Body.Close()
 	}(resp.Body)
 
-	return resp.StatusCode < 400
+	result := analyzer.LinkResult{
+		IsAccessible:  resp.StatusCode < 400,
+		StatusCode:    resp.StatusCode,
+		FinalURL:      resp.Request.URL.String(),
+		Latency:       time.Since(start),
+		ContentLength: resp.ContentLength,
+	}
+	switch {
+	case resp.StatusCode >= 500:
+		result.ErrorClass = analyzer.LinkErrorServerError
+	case resp.StatusCode >= 400:
+		result.ErrorClass = analyzer.LinkErrorClientError
+	}
+	return result
+}
+
+// maps a transport error to one of the link error classes
+func classifyLinkError(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+
+	switch {
+	case errors.Is(err, ErrBlockedDestination):
+		return analyzer.LinkErrorBlockedDestination
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return analyzer.LinkErrorTimeout
+	case errors.As(err, &dnsErr):
+		return analyzer.LinkErrorDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return analyzer.LinkErrorConnectionRefused
+	case errors.As(err, &certErr), errors.As(err, &recordErr):
+		return analyzer.LinkErrorTLS
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return analyzer.LinkErrorTimeout
+	default:
+		return analyzer.LinkErrorNetwork
+	}
 }
 
 // checks the accessibility of a list of links, categorizing them as internal or external
-func analyzeLinks(links []string, baseURL *url.URL) (internal, external, inaccessible int) {
+// results are returned in the same order as the given links, onResult (if not nil) is called from the workers as each check finishes
+func analyzeLinks(ctx context.Context, check *linkCheck, links []analyzer.Link, baseURL *url.URL, opts model.AnalyzeOptions, onResult func(model.LinkDetail)) []model.LinkDetail {
 	if len(links) == 0 {
-		return 0, 0, 0
+		return nil
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	linkJobs := make(chan string, len(links))
-	results := make(chan analyzer.LinkResult, len(links))
+	details := make([]model.LinkDetail, len(links))
+	linkJobs := make(chan int, len(links))
 
 	numWorkers := analyzer.MaxLinkCheckWorkers
 	if len(links) < numWorkers {
@@ -323,45 +655,79 @@ func analyzeLinks(links []string, baseURL *url.URL) (internal, external, inacces
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for link := range linkJobs {
+			for idx := range linkJobs {
+				link := links[idx]
+				var result analyzer.LinkResult
 				select {
 				case <-ctx.Done():
-					return
+					result = analyzer.LinkResult{ErrorClass: analyzer.LinkErrorTimeout}
 				default:
-					isInternal := isInternalLink(link, baseURL)
-					isAccessible := checkLinkAccessibility(ctx, link, baseURL)
-					results <- analyzer.LinkResult{
-						IsInternal:   isInternal,
-						IsAccessible: isAccessible,
-					}
+					result = checkLinkAccessibility(ctx, check, link.Href, baseURL, opts)
+				}
+				result.IsInternal = isInternalLink(link.Href, baseURL)
+				details[idx] = newLinkDetail(link, baseURL, result)
+				if onResult != nil {
+					onResult(details[idx])
 				}
 			}
 		}()
 	}
 
-	go func() {
-		for _, link := range links {
-			linkJobs <- link
-		}
-		close(linkJobs)
-	}()
+	for i := range links {
+		linkJobs <- i
+	}
+	close(linkJobs)
 
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+	wg.Wait()
+
+	return details
+}
 
-	for result := range results {
-		if result.IsInternal {
+// reports the links as skipped without requesting them
+func skipLinks(links []analyzer.Link, baseURL *url.URL) []model.LinkDetail {
+	if len(links) == 0 {
+		return nil
+	}
+
+	details := make([]model.LinkDetail, len(links))
+	for i, link := range links {
+		result := analyzer.LinkResult{IsAccessible: true, Skipped: true, IsInternal: isInternalLink(link.Href, baseURL)}
+		details[i] = newLinkDetail(link, baseURL, result)
+	}
+	return details
+}
+
+// builds the reported link record from the extracted link and its check result
+func newLinkDetail(link analyzer.Link, baseURL *url.URL, result analyzer.LinkResult) model.LinkDetail {
+	detail := model.LinkDetail{
+		Href:         link.Href,
+		IsInternal:   result.IsInternal,
+		AnchorText:   link.Text,
+		IsAccessible: result.IsAccessible,
+		Skipped:      result.Skipped,
+		StatusCode:   result.StatusCode,
+		FinalURL:     result.FinalURL,
+		ErrorClass:   result.ErrorClass,
+		LatencyMs:    result.Latency.Milliseconds(),
+	}
+	if parsed, err := url.Parse(link.Href); err == nil {
+		detail.ResolvedURL = baseURL.ResolveReference(parsed).String()
+	}
+	return detail
+}
+
+// counts internal, external and inaccessible links
+func countLinks(links []model.LinkDetail) (internal, external, inaccessible int) {
+	for _, link := range links {
+		if link.IsInternal {
 			internal++
 		} else {
 			external++
 		}
-		if !result.IsAccessible {
+		if !link.IsAccessible {
 			inaccessible++
 		}
 	}
-
 	return
 }
 
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"go.uber.org/zap"
 	"golang.org/x/net/context"
@@ -8,11 +10,15 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 	"webanalyzer/internal/log"
 	"webanalyzer/internal/model"
+	"webanalyzer/internal/util/analyzer"
 )
 
 func TestDetectHTMLVersion(t *testing.T) {
@@ -50,9 +56,9 @@ func TestDetectHTMLVersion(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := detectHTMLVersion(tt.rawHTML)
+			result := parseDoctype(tt.rawHTML, "").Version
 			if result != tt.expected {
-				t.Errorf("detectHTMLVersion() = %v, want %v", result, tt.expected)
+				t.Errorf("parseDoctype().Version = %v, want %v", result, tt.expected)
 			}
 		})
 	}
@@ -170,6 +176,54 @@ func TestExtractHeadings(t *testing.T) {
 	}
 }
 
+func TestExtractHeadingOutline(t *testing.T) {
+	node, err := html.Parse(strings.NewReader(`<html><body>
+		<h1>Guide</h1>
+		<h2>Install</h2>
+		<h4>On  <em>Linux</em></h4>
+		<h3>On macOS</h3>
+		<h2></h2>
+		<h1>Appendix</h1>
+		<h2>FAQ</h2>
+	</body></html>`))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	outline := extractHeadingOutline(node)
+
+	expected := []*model.HeadingNode{
+		{Level: 1, Text: "Guide", Children: []*model.HeadingNode{
+			{Level: 2, Text: "Install", Children: []*model.HeadingNode{
+				{Level: 4, Text: "On Linux"},
+				{Level: 3, Text: "On macOS"},
+			}},
+			{Level: 2, Text: ""},
+		}},
+		{Level: 1, Text: "Appendix", Children: []*model.HeadingNode{
+			{Level: 2, Text: "FAQ"},
+		}},
+	}
+	if !reflect.DeepEqual(outline.Headings, expected) {
+		got, _ := json.Marshal(outline.Headings)
+		t.Errorf("extractHeadingOutline() headings = %s", got)
+	}
+
+	var issues []string
+	for _, issue := range outline.Issues {
+		issues = append(issues, issue.Type)
+	}
+	if !reflect.DeepEqual(issues, []string{"level_jump", "empty_heading", "multiple_h1"}) {
+		t.Errorf("extractHeadingOutline() issues = %v", outline.Issues)
+	}
+
+	empty, _ := html.Parse(strings.NewReader(`<html><body><p>No headings</p></body></html>`))
+	outline = extractHeadingOutline(empty)
+	if len(outline.Headings) != 0 || len(outline.Issues) != 1 || outline.Issues[0].Type != "missing_h1" {
+		t.Errorf("extractHeadingOutline() without headings = %+v", outline)
+	}
+}
+
 func TestExtractLinks(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -215,7 +269,7 @@ func TestExtractLinks(t *testing.T) {
 				return
 			}
 			for i, link := range result {
-				if link != tt.expected[i] {
+				if link.Href != tt.expected[i] {
 					t.Errorf("extractLinks()[%d] = %v, want %v", i, link, tt.expected[i])
 				}
 			}
@@ -284,6 +338,7 @@ func TestIsInternalLink(t *testing.T) {
 }
 
 func TestCheckLinkAccessibility(t *testing.T) {
+	log.Logger = zap.NewNop()
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case "/ok":
@@ -341,8 +396,8 @@ func TestCheckLinkAccessibility(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx, _ := context.WithTimeout(context.Background(), 30*time.Second)
-			result := checkLinkAccessibility(ctx, tt.link, baseURL)
-			if result != tt.expected {
+			result := checkLinkAccessibility(ctx, nil, tt.link, baseURL, model.AnalyzeOptions{})
+			if result.IsAccessible != tt.expected {
 				t.Errorf("checkLinkAccessibility(%q) = %v, want %v", tt.link, result, tt.expected)
 			}
 		})
@@ -350,6 +405,7 @@ func TestCheckLinkAccessibility(t *testing.T) {
 }
 
 func TestAnalyzeLinks(t *testing.T) {
+	log.Logger = zap.NewNop()
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/accessible" {
 			w.WriteHeader(http.StatusOK)
@@ -402,7 +458,11 @@ func TestAnalyzeLinks(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			internal, external, inaccessible := analyzeLinks(tt.links, baseURL)
+			links := make([]analyzer.Link, len(tt.links))
+			for i, href := range tt.links {
+				links[i] = analyzer.Link{Href: href}
+			}
+			internal, external, inaccessible := countLinks(analyzeLinks(context.Background(), nil, links, baseURL, model.AnalyzeOptions{}, nil))
 			if internal != tt.expectedInternal {
 				t.Errorf("analyzeLinks() internal = %d, want %d", internal, tt.expectedInternal)
 			}
@@ -416,6 +476,74 @@ func TestAnalyzeLinks(t *testing.T) {
 	}
 }
 
+func TestAnalyzeLinksDetails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/moved":
+			http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+		case "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL)
+	node, err := html.Parse(strings.NewReader(`<html><body>
+		<a href="/ok">  Home
+			page </a>
+		<a href="/moved">Moved</a>
+		<a href="/broken">Broken</a>
+		<a href="/missing">Missing</a>
+		<a href="mailto:test@example.com">Mail</a>
+	</body></html>`))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	details := analyzeLinks(context.Background(), nil, extractLinks(node), baseURL, model.AnalyzeOptions{}, nil)
+
+	expected := []model.LinkDetail{
+		{Href: "/ok", ResolvedURL: server.URL + "/ok", IsInternal: true, AnchorText: "Home page", IsAccessible: true, StatusCode: 200, FinalURL: server.URL + "/ok"},
+		{Href: "/moved", ResolvedURL: server.URL + "/moved", IsInternal: true, AnchorText: "Moved", IsAccessible: true, StatusCode: 200, FinalURL: server.URL + "/ok"},
+		{Href: "/broken", ResolvedURL: server.URL + "/broken", IsInternal: true, AnchorText: "Broken", StatusCode: 500, FinalURL: server.URL + "/broken", ErrorClass: analyzer.LinkErrorServerError},
+		{Href: "/missing", ResolvedURL: server.URL + "/missing", IsInternal: true, AnchorText: "Missing", StatusCode: 404, FinalURL: server.URL + "/missing", ErrorClass: analyzer.LinkErrorClientError},
+		{Href: "mailto:test@example.com", ResolvedURL: "mailto:test@example.com", AnchorText: "Mail", IsAccessible: true, Skipped: true},
+	}
+
+	if len(details) != len(expected) {
+		t.Fatalf("analyzeLinks() returned %d details, want %d", len(details), len(expected))
+	}
+	for i, want := range expected {
+		got := details[i]
+		got.LatencyMs = 0
+		if got != want {
+			t.Errorf("analyzeLinks()[%d] = %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func TestClassifyLinkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closedURL := server.URL
+	server.Close()
+
+	baseURL, _ := url.Parse(closedURL)
+	result := checkLinkAccessibility(context.Background(), nil, closedURL+"/page", baseURL, model.AnalyzeOptions{})
+	if result.ErrorClass != analyzer.LinkErrorConnectionRefused {
+		t.Errorf("checkLinkAccessibility() error class = %q, want %q", result.ErrorClass, analyzer.LinkErrorConnectionRefused)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := classifyLinkError(ctx.Err()); got != analyzer.LinkErrorTimeout {
+		t.Errorf("classifyLinkError(canceled) = %q, want %q", got, analyzer.LinkErrorTimeout)
+	}
+}
+
 func TestHasLoginForm(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -535,7 +663,7 @@ func TestFetchHTML(t *testing.T) {
 			server := httptest.NewServer(http.HandlerFunc(tt.serverResponse))
 			defer server.Close()
 
-			node, rawHTML, err := fetchHTML(server.URL)
+			fetched, err := fetchHTML(context.Background(), server.URL, model.AnalyzeOptions{})
 
 			if tt.expectError {
 				if err == nil {
@@ -545,13 +673,168 @@ func TestFetchHTML(t *testing.T) {
 				if err != nil {
 					t.Errorf("fetchHTML() unexpected error: %v", err)
 				}
-				if node == nil {
+				if fetched.root == nil {
 					t.Error("fetchHTML() returned nil node")
 				}
-				if rawHTML == "" {
+				if fetched.rawHTML == "" {
 					t.Error("fetchHTML() returned empty rawHTML")
 				}
 			}
 		})
 	}
 }
+
+func TestAnalyzePageWithEvents(t *testing.T) {
+	log.Logger = zap.NewNop()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			fmt.Fprint(w, `<!DOCTYPE html><html><head><title>Events</title></head><body><h1>Hi</h1><a href="/a">A</a><a href="/b">B</a></body></html>`)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var events []model.AnalysisEvent
+	page, err := AnalyzePageWithEvents(context.Background(), server.URL+"/", model.AnalyzeOptions{}, func(event model.AnalysisEvent) {
+		events = append(events, event)
+	})
+	if err != nil {
+		t.Fatalf("AnalyzePageWithEvents() unexpected error: %v", err)
+	}
+
+	if len(events) == 0 || events[0].Type != model.EventStage || events[0].Stage != model.JobFetching {
+		t.Fatalf("first event = %+v, want fetching stage", events)
+	}
+	if last := events[len(events)-1]; last.Type != model.EventSummary || last.Data != page {
+		t.Errorf("last event = %+v, want summary with the returned page", last)
+	}
+
+	seen := map[model.EventType]int{}
+	checked := 0
+	for _, event := range events {
+		seen[event.Type]++
+		if event.Type == model.EventLink {
+			if seen[model.EventStage] != 2 {
+				t.Error("link event before the checking links stage")
+			}
+			progress := event.Data.(model.LinkProgress)
+			checked++
+			if progress.Checked != checked || progress.Total != 2 {
+				t.Errorf("link progress = %d/%d, want %d/2", progress.Checked, progress.Total, checked)
+			}
+		}
+	}
+	for _, eventType := range []model.EventType{model.EventFetched, model.EventTitle, model.EventHeadings, model.EventHTMLVersion, model.EventLoginForm} {
+		if seen[eventType] != 1 {
+			t.Errorf("got %d %s events, want 1", seen[eventType], eventType)
+		}
+	}
+	if seen[model.EventLink] != 2 || seen[model.EventStage] != 2 {
+		t.Errorf("got %d link and %d stage events, want 2 and 2", seen[model.EventLink], seen[model.EventStage])
+	}
+}
+
+func TestAnalyzePageRobots(t *testing.T) {
+	log.Logger = zap.NewNop()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private\n\nUser-agent: WebAnalyzer\nDisallow: /blocked\nDisallow: /hidden-link\n")
+		case "/hidden-link":
+			t.Error("link disallowed by robots.txt was requested")
+		case "/blocked":
+			fmt.Fprint(w, `<html><body><h1>Blocked</h1></body></html>`)
+		default:
+			fmt.Fprint(w, `<html><body><a href="/hidden-link">Hidden</a><a href="/private">Private</a></body></html>`)
+		}
+	}))
+	defer server.Close()
+
+	page, err := AnalyzePage(context.Background(), server.URL+"/blocked", model.AnalyzeOptions{})
+	if !errors.Is(err, ErrRobotsDisallowed) {
+		t.Fatalf("AnalyzePage() error = %v, want %v", err, ErrRobotsDisallowed)
+	}
+	if page.Robots == nil || page.Robots.Allowed {
+		t.Errorf("robots status = %+v, want disallowed", page.Robots)
+	}
+
+	page, err = AnalyzePage(context.Background(), server.URL+"/page", model.AnalyzeOptions{IncludeLinks: true})
+	if err != nil {
+		t.Fatalf("AnalyzePage() unexpected error: %v", err)
+	}
+	if !page.Robots.Allowed || page.Robots.RobotsURL != server.URL+"/robots.txt" {
+		t.Errorf("robots status = %+v, want allowed", page.Robots)
+	}
+	if link := page.Links[0]; !link.Skipped || link.ErrorClass != analyzer.LinkErrorRobotsDisallowed {
+		t.Errorf("disallowed link = %+v, want skipped by robots", link)
+	}
+	if link := page.Links[1]; link.Skipped || link.StatusCode != http.StatusOK {
+		t.Errorf("link only disallowed for other agents = %+v, want checked", link)
+	}
+
+	page, err = AnalyzePage(context.Background(), server.URL+"/blocked", model.AnalyzeOptions{IgnoreRobots: true})
+	if err != nil {
+		t.Fatalf("AnalyzePage() with ignored robots unexpected error: %v", err)
+	}
+	if page.Robots.Allowed || !page.Robots.Ignored {
+		t.Errorf("robots status = %+v, want disallowed and ignored", page.Robots)
+	}
+}
+
+func TestAnalyzePageCrawlDelay(t *testing.T) {
+	log.Logger = zap.NewNop()
+	delay := 200 * time.Millisecond
+
+	var mu sync.Mutex
+	var requests []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprintf(w, "User-agent: *\nCrawl-delay: %.1f\n", delay.Seconds())
+			return
+		case "/":
+			fmt.Fprint(w, `<html><head><meta property="og:image" content="/og.png"></head><body><a href="/a">A</a><a href="/b">B</a><img src="/c.png"></body></html>`)
+		}
+		requests = append(requests, time.Now())
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		opts     model.AnalyzeOptions
+		requests int
+		spaced   bool
+	}{
+		{name: "Crawl-delay honored", opts: model.AnalyzeOptions{CheckResources: true}, requests: 5, spaced: true},
+		{name: "robots ignored", opts: model.AnalyzeOptions{CheckResources: true, IgnoreRobots: true}, requests: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = nil
+			if _, err := AnalyzePage(context.Background(), server.URL, tt.opts); err != nil {
+				t.Fatalf("AnalyzePage() unexpected error: %v", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(requests) != tt.requests {
+				t.Fatalf("got %d requests, want %d", len(requests), tt.requests)
+			}
+			sort.Slice(requests, func(i, j int) bool { return requests[i].Before(requests[j]) })
+			spaced := true
+			for i := 1; i < len(requests); i++ {
+				// the limiter may release a request slightly early
+				if requests[i].Sub(requests[i-1]) < delay-20*time.Millisecond {
+					spaced = false
+				}
+			}
+			if spaced != tt.spaced {
+				t.Errorf("requests spaced by the Crawl-delay = %v, want %v", spaced, tt.spaced)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/util"
+	"webanalyzer/internal/util/analyzer"
+)
+
+var errInvalidURL = errors.New("invalid 'url' format")
+
+// AnalyzeBatch analyzes every URL with a bounded pool of workers, waiting between requests to the same host.
+// onResult (if not nil) is called as soon as each URL is done and is never called concurrently.
+// the returned results are in the same order as urls
+func AnalyzeBatch(ctx context.Context, urls []string, opts model.AnalyzeOptions, onResult func(model.BatchResult)) []model.BatchResult {
+	results := make([]model.BatchResult, len(urls))
+	if len(urls) == 0 {
+		return results
+	}
+
+	hosts := newHostLimiter(hostRequestInterval)
+	jobs := make(chan int, len(urls))
+
+	numWorkers := analyzer.MaxBatchWorkers
+	if len(urls) < numWorkers {
+		numWorkers = len(urls)
+	}
+
+	var resultMu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				result := analyzeBatchURL(ctx, hosts, urls[idx], opts)
+				result.Index = idx
+
+				resultMu.Lock()
+				results[idx] = result
+				if onResult != nil {
+					onResult(result)
+				}
+				resultMu.Unlock()
+			}
+		}()
+	}
+
+	for i := range urls {
+		jobs <- i
+	}
+	close(jobs)
+
+	wg.Wait()
+
+	return results
+}
+
+// analyzes a single URL of a batch once its host allows another request
+func analyzeBatchURL(ctx context.Context, hosts *hostLimiter, targetURL string, opts model.AnalyzeOptions) model.BatchResult {
+	result := model.BatchResult{URL: targetURL}
+
+	parsed, err := url.Parse(targetURL)
+	if err != nil || !util.IsValidURL(targetURL) {
+		result.Error = errInvalidURL.Error()
+		return result
+	}
+
+	if err := waitForHost(ctx, hosts, parsed, opts); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	page, err := AnalyzePage(ctx, targetURL, opts)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Result = page
+	return result
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"webanalyzer/internal/log"
+	"webanalyzer/internal/model"
+)
+
+func TestAnalyzeBatch(t *testing.T) {
+	log.Logger = zap.NewNop()
+	defer func(interval time.Duration) { hostRequestInterval = interval }(hostRequestInterval)
+	hostRequestInterval = time.Millisecond
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, "<html><head><title>%s</title></head></html>", r.URL.Path)
+	}))
+	defer server.Close()
+
+	urls := []string{server.URL + "/first", "not a url", server.URL + "/missing"}
+
+	var streamed []int
+	results := AnalyzeBatch(context.Background(), urls, model.AnalyzeOptions{}, func(result model.BatchResult) {
+		streamed = append(streamed, result.Index)
+	})
+
+	if len(results) != len(urls) || len(streamed) != len(urls) {
+		t.Fatalf("AnalyzeBatch() returned %d results and streamed %d, want %d", len(results), len(streamed), len(urls))
+	}
+
+	if results[0].Error != "" || results[0].Result == nil || results[0].Result.PageTitle != "/first" {
+		t.Errorf("results[0] = %+v, want page titled /first", results[0])
+	}
+	if results[1].Error != errInvalidURL.Error() || results[1].Result != nil {
+		t.Errorf("results[1] = %+v, want invalid url error", results[1])
+	}
+	if results[2].Error == "" || results[2].Result != nil {
+		t.Errorf("results[2] = %+v, want fetch error", results[2])
+	}
+	for i, result := range results {
+		if result.Index != i || result.URL != urls[i] {
+			t.Errorf("results[%d] has index %d and url %q", i, result.Index, result.URL)
+		}
+	}
+}
+
+func TestHostLimiter(t *testing.T) {
+	limiter := newHostLimiter(50 * time.Millisecond)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(ctx, "Example.com"); err != nil {
+			t.Fatalf("Wait() unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("three requests to one host took %v, want at least 100ms", elapsed)
+	}
+
+	start = time.Now()
+	if err := limiter.Wait(ctx, "other.com"); err != nil {
+		t.Fatalf("Wait() unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Errorf("first request to another host waited %v", elapsed)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := limiter.Wait(canceled, "example.com"); err == nil {
+		t.Error("Wait() with canceled context expected error but got none")
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/util/analyzer"
+)
+
+var (
+	ErrUnsupportedContentType     = errors.New("target is not an HTML page")
+	ErrUnsupportedContentEncoding = errors.New("unsupported content encoding")
+)
+
+// FetchLimits bounds what is read from the analyzed page
+type FetchLimits struct {
+	// MaxBodyBytes is the number of decoded bytes analyzed, longer pages are truncated
+	MaxBodyBytes int64
+	// AllowNonHTML analyzes pages declared as another media type instead of refusing them
+	AllowNonHTML bool
+}
+
+var (
+	fetchLimitsMu sync.RWMutex
+	fetchLimits   = FetchLimits{MaxBodyBytes: analyzer.DefaultMaxBodyBytes}
+)
+
+// SetFetchLimits replaces the body size and content type limits of the page fetches
+func SetFetchLimits(limits FetchLimits) {
+	if limits.MaxBodyBytes <= 0 {
+		limits.MaxBodyBytes = analyzer.DefaultMaxBodyBytes
+	}
+
+	fetchLimitsMu.Lock()
+	defer fetchLimitsMu.Unlock()
+	fetchLimits = limits
+}
+
+// MaxBodyBytes is the number of bytes of a page that are analyzed
+func MaxBodyBytes() int64 {
+	return currentFetchLimits().MaxBodyBytes
+}
+
+func currentFetchLimits() FetchLimits {
+	fetchLimitsMu.RLock()
+	defer fetchLimitsMu.RUnlock()
+	return fetchLimits
+}
+
+// the media types analyzed as HTML
+var htmlMediaTypes = map[string]bool{
+	"text/html":             true,
+	"application/xhtml+xml": true,
+}
+
+// the lower cased media type of a Content-Type value, empty when it is missing or malformed
+func parseMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// describes the declared content of resp, refusing non-HTML media types unless limits allow them
+func checkContentType(resp *http.Response, limits FetchLimits) (*model.Content, error) {
+	content := &model.Content{
+		ContentType: resp.Header.Get("Content-Type"),
+		Warnings:    []string{},
+	}
+	content.MediaType = parseMediaType(content.ContentType)
+	content.IsHTML = htmlMediaTypes[content.MediaType]
+
+	if content.MediaType != "" && !content.IsHTML {
+		if !limits.AllowNonHTML {
+			return content, fmt.Errorf("%w: served as %s", ErrUnsupportedContentType, content.MediaType)
+		}
+		content.Warnings = append(content.Warnings, fmt.Sprintf("page is served as %s, it was analyzed as HTML", content.MediaType))
+	}
+	return content, nil
+}
+
+// fills in the media type of a response without Content-Type from its first bytes
+func sniffContentType(content *model.Content, body []byte) {
+	if content.MediaType != "" {
+		return
+	}
+	content.MediaType = parseMediaType(http.DetectContentType(body))
+	content.IsHTML = htmlMediaTypes[content.MediaType]
+	content.Warnings = append(content.Warnings, fmt.Sprintf("page is served without a Content-Type, the body looks like %s", content.MediaType))
+}
+
+// reads the decoded body of resp into content, at most maxBytes of it.
+// hitting the limit or a suspicious compression ratio truncates the body and records a warning instead of failing
+func readBody(resp *http.Response, content *model.Content, maxBytes int64) ([]byte, error) {
+	wire := &countingReader{r: resp.Body}
+	var decoded io.Reader = wire
+
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	switch encoding {
+	case "", "identity":
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(wire)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode gzip body: %w", err)
+		}
+		defer gz.Close()
+		decoded = gz
+	case "deflate":
+		zr, err := zlib.NewReader(wire)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode deflate body: %w", err)
+		}
+		defer zr.Close()
+		decoded = zr
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedContentEncoding, encoding)
+	}
+	content.ContentEncoding = encoding
+	compressed := decoded != wire
+
+	var body bytes.Buffer
+	limited := io.LimitReader(decoded, maxBytes+1)
+	chunk := make([]byte, 32*1024)
+	for {
+		n, err := limited.Read(chunk)
+		body.Write(chunk[:n])
+
+		if compressed && body.Len() >= analyzer.DecompressionCheckBytes && int64(body.Len()) > wire.n*analyzer.MaxCompressionRatio {
+			content.Truncated = true
+			content.Warnings = append(content.Warnings, fmt.Sprintf("body decompressed to more than %dx its transfer size, only the first %d bytes were analyzed", analyzer.MaxCompressionRatio, body.Len()))
+			break
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		// a dropped connection or a body cut short by a recording
+		if errors.Is(err, io.ErrUnexpectedEOF) && body.Len() > 0 {
+			content.Truncated = true
+			content.Warnings = append(content.Warnings, fmt.Sprintf("body ended early, only the first %d bytes were analyzed", body.Len()))
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if int64(body.Len()) > maxBytes {
+		body.Truncate(int(maxBytes))
+		content.Truncated = true
+		content.Warnings = append(content.Warnings, fmt.Sprintf("body exceeds the %d byte limit, only the first %d bytes were analyzed", maxBytes, maxBytes))
+	}
+
+	content.TransferBytes = wire.n
+	content.BodyBytes = body.Len()
+	return body.Bytes(), nil
+}
+
+// countingReader counts the bytes read from the connection
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"webanalyzer/internal/log"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/util/analyzer"
+)
+
+func TestFetchHTMLLimits(t *testing.T) {
+	log.Logger = zap.NewNop()
+
+	var bomb bytes.Buffer
+	gz := gzip.NewWriter(&bomb)
+	gz.Write([]byte("<html><body>"))
+	gz.Write(make([]byte, 8*analyzer.DecompressionCheckBytes))
+	gz.Close()
+
+	var small bytes.Buffer
+	gz = gzip.NewWriter(&small)
+	gz.Write([]byte("<html><head><title>Compressed</title></head></html>"))
+	gz.Close()
+
+	page := "<html><head><title>Long</title></head><body>" + strings.Repeat("<p>filler</p>", 200) + "</body></html>"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/long":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(page))
+		case "/gzip":
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(small.Bytes())
+		case "/bomb":
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(bomb.Bytes())
+		case "/pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("%PDF-1.7"))
+		case "/brotli":
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Set("Content-Encoding", "br")
+			w.Write([]byte{0x1b})
+		case "/untyped":
+			w.Header()["Content-Type"] = nil
+			w.Write([]byte("<!DOCTYPE html><html><head><title>Untyped</title></head></html>"))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		path      string
+		limits    FetchLimits
+		wantErr   error
+		bodyBytes int
+		truncated bool
+		warnings  int
+		mediaType string
+	}{
+		{name: "body over the limit", path: "/long", limits: FetchLimits{MaxBodyBytes: 100}, bodyBytes: 100, truncated: true, warnings: 1, mediaType: "text/html"},
+		{name: "gzip body", path: "/gzip", limits: FetchLimits{MaxBodyBytes: 1024}, bodyBytes: 51, mediaType: "text/html"},
+		{name: "decompression bomb", path: "/bomb", limits: FetchLimits{MaxBodyBytes: 64 * analyzer.DecompressionCheckBytes}, truncated: true, warnings: 1, mediaType: "text/html"},
+		{name: "non-HTML refused", path: "/pdf", limits: FetchLimits{MaxBodyBytes: 1024}, wantErr: ErrUnsupportedContentType},
+		{name: "non-HTML flagged", path: "/pdf", limits: FetchLimits{MaxBodyBytes: 1024, AllowNonHTML: true}, bodyBytes: 8, warnings: 1, mediaType: "application/pdf"},
+		{name: "unsupported encoding", path: "/brotli", limits: FetchLimits{MaxBodyBytes: 1024}, wantErr: ErrUnsupportedContentEncoding},
+		{name: "missing content type", path: "/untyped", limits: FetchLimits{MaxBodyBytes: 1024}, bodyBytes: 63, warnings: 1, mediaType: "text/html"},
+	}
+
+	defer SetFetchLimits(FetchLimits{MaxBodyBytes: analyzer.DefaultMaxBodyBytes})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetFetchLimits(tt.limits)
+
+			fetched, err := fetchHTML(context.Background(), server.URL+tt.path, model.AnalyzeOptions{})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("fetchHTML() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetchHTML() unexpected error: %v", err)
+			}
+
+			content := fetched.content
+			if content.Truncated != tt.truncated || len(content.Warnings) != tt.warnings || content.MediaType != tt.mediaType {
+				t.Errorf("content = %+v, want truncated %v with %d warnings and media type %s", content, tt.truncated, tt.warnings, tt.mediaType)
+			}
+			if tt.bodyBytes > 0 && content.BodyBytes != tt.bodyBytes {
+				t.Errorf("body bytes = %d, want %d", content.BodyBytes, tt.bodyBytes)
+			}
+			if content.BodyBytes != len(fetched.rawHTML) || int64(content.BodyBytes) > tt.limits.MaxBodyBytes {
+				t.Errorf("body bytes = %d, raw HTML = %d bytes, limit = %d", content.BodyBytes, len(fetched.rawHTML), tt.limits.MaxBodyBytes)
+			}
+		})
+	}
+}
+
+func TestAnalyzePageTruncated(t *testing.T) {
+	log.Logger = zap.NewNop()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><head><title>Endless</title></head><body>"))
+		for range 64 {
+			w.Write(bytes.Repeat([]byte("<p>more</p>"), 1024))
+		}
+	}))
+	defer server.Close()
+
+	defer SetFetchLimits(FetchLimits{MaxBodyBytes: analyzer.DefaultMaxBodyBytes})
+	SetFetchLimits(FetchLimits{MaxBodyBytes: 4096})
+
+	page, err := AnalyzePage(context.Background(), server.URL, model.AnalyzeOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzePage() unexpected error: %v", err)
+	}
+	if page.PageTitle != "Endless" || page.Content == nil || !page.Content.Truncated {
+		t.Errorf("AnalyzePage() title = %q, content = %+v, want a truncated analysis", page.PageTitle, page.Content)
+	}
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/sitemap"
+	"webanalyzer/internal/util/analyzer"
+)
+
+// Crawl analyzes the page at startURL and follows its internal links breadth-first,
+// up to maxDepth levels and maxPages pages, and aggregates the results into a site report.
+// zero limits fall back to the defaults, larger ones are capped
+func Crawl(ctx context.Context, startURL string, maxDepth, maxPages int, opts model.AnalyzeOptions) (*model.SiteReport, error) {
+	start, err := url.Parse(startURL)
+	if err != nil {
+		return nil, err
+	}
+	startKey := normalizeCrawlURL(start)
+	if startKey == "" {
+		return nil, errInvalidURL
+	}
+	maxDepth, maxPages = crawlLimits(maxDepth, maxPages)
+
+	report := &model.SiteReport{StartURL: startKey}
+	hosts := newHostLimiter(hostRequestInterval)
+	visited := map[string]bool{startKey: true}
+	inbound := make(map[string]int)
+
+	level := []string{startKey}
+	for depth := 0; len(level) > 0 && depth <= maxDepth; depth++ {
+		if ctx.Err() != nil {
+			break
+		}
+
+		remaining := maxPages - len(report.Pages)
+		if remaining <= 0 {
+			report.Truncated = true
+			break
+		}
+		if len(level) > remaining {
+			level = level[:remaining]
+			report.Truncated = true
+		}
+
+		var next []string
+		for _, crawled := range crawlLevel(ctx, hosts, level, depth, opts) {
+			if depth == 0 && crawled.err != nil {
+				return nil, crawled.err
+			}
+			report.Pages = append(report.Pages, crawled.page)
+			report.MaxDepthReached = depth
+
+			for _, link := range crawled.links {
+				if !link.IsInternal {
+					continue
+				}
+				target := normalizeLinkURL(link.ResolvedURL)
+				if target == "" || target == crawled.page.URL {
+					continue
+				}
+				// a skipped link still links to the page, it just was not checked
+				inbound[target]++
+				if link.Skipped {
+					continue
+				}
+
+				if !link.IsAccessible {
+					report.BrokenInternalLinks = append(report.BrokenInternalLinks, model.BrokenLink{
+						SourceURL:  crawled.page.URL,
+						TargetURL:  target,
+						StatusCode: link.StatusCode,
+						ErrorClass: link.ErrorClass,
+					})
+					continue
+				}
+
+				if !visited[target] && depth < maxDepth {
+					visited[target] = true
+					next = append(next, target)
+				}
+			}
+		}
+		level = next
+	}
+
+	summarizeCrawl(report)
+	// a truncated crawl misses the links of the pages it did not reach
+	if ctx.Err() == nil && !report.Truncated {
+		report.OrphanPages = findOrphanPages(ctx, startKey, inbound)
+	}
+
+	return report, ctx.Err()
+}
+
+type crawlResult struct {
+	page  model.CrawledPage
+	links []model.LinkDetail
+	err   error
+}
+
+// analyzes all pages of one crawl level in parallel, results keep the order of urls
+func crawlLevel(ctx context.Context, hosts *hostLimiter, urls []string, depth int, opts model.AnalyzeOptions) []crawlResult {
+	results := make([]crawlResult, len(urls))
+	jobs := make(chan int, len(urls))
+
+	numWorkers := analyzer.MaxCrawlWorkers
+	if len(urls) < numWorkers {
+		numWorkers = len(urls)
+	}
+
+	pageOpts := opts
+	pageOpts.IncludeLinks = true
+
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = crawlPage(ctx, hosts, urls[idx], depth, pageOpts, opts.IncludeLinks)
+			}
+		}()
+	}
+
+	for i := range urls {
+		jobs <- i
+	}
+	close(jobs)
+
+	wg.Wait()
+
+	return results
+}
+
+// analyzes a single page and keeps its links for discovering the next level
+func crawlPage(ctx context.Context, hosts *hostLimiter, pageURL string, depth int, opts model.AnalyzeOptions, keepLinks bool) crawlResult {
+	result := crawlResult{page: model.CrawledPage{URL: pageURL, Depth: depth}}
+
+	parsed, _ := url.Parse(pageURL)
+	if err := waitForHost(ctx, hosts, parsed, opts); err != nil {
+		result.err = err
+		result.page.Error = err.Error()
+		return result
+	}
+
+	analysis, err := AnalyzePage(ctx, pageURL, opts)
+	if err != nil {
+		result.err = err
+		result.page.Error = err.Error()
+		return result
+	}
+
+	result.links = analysis.Links
+	if !keepLinks {
+		analysis.Links = nil
+	}
+	result.page.Analysis = analysis
+	return result
+}
+
+// fills in the site level statistics from the crawled pages
+func summarizeCrawl(report *model.SiteReport) {
+	for _, page := range report.Pages {
+		if page.Analysis == nil {
+			report.PagesFailed++
+			continue
+		}
+		report.PagesCrawled++
+
+		analysis := page.Analysis
+		if strings.TrimSpace(analysis.PageTitle) == "" {
+			report.PagesMissingTitle = append(report.PagesMissingTitle, page.URL)
+		}
+		switch {
+		case analysis.HeadingCounts.H1 == 0:
+			report.PagesWithoutH1 = append(report.PagesWithoutH1, page.URL)
+		case analysis.HeadingCounts.H1 > 1:
+			report.PagesWithMultipleH1 = append(report.PagesWithMultipleH1, page.URL)
+		}
+
+		report.HeadingTotals.H1 += analysis.HeadingCounts.H1
+		report.HeadingTotals.H2 += analysis.HeadingCounts.H2
+		report.HeadingTotals.H3 += analysis.HeadingCounts.H3
+		report.HeadingTotals.H4 += analysis.HeadingCounts.H4
+		report.HeadingTotals.H5 += analysis.HeadingCounts.H5
+		report.HeadingTotals.H6 += analysis.HeadingCounts.H6
+
+		report.InternalLinkCount += analysis.InternalLinkCount
+		report.ExternalLinkCount += analysis.ExternalLinkCount
+		report.InaccessibleLinkCount += analysis.InaccessibleLinkCount
+	}
+}
+
+// returns the sitemap URLs of the crawled site, other than the start page, that no crawled page links to.
+// links are only followed from pages, so the sitemap is the only place such pages can be found
+func findOrphanPages(ctx context.Context, startKey string, inbound map[string]int) []string {
+	start, _ := url.Parse(startKey)
+	client := &http.Client{Timeout: analyzer.SitemapFetchTimeout, Transport: currentTransport()}
+	loaded := sitemap.Load(ctx, client, analyzer.UserAgent, discoverSitemaps(ctx, start), analyzer.MaxSitemapURLs)
+
+	var orphans []string
+	seen := make(map[string]bool)
+	for _, entry := range loaded.URLs {
+		target := normalizeLinkURL(entry.Loc)
+		if target == "" || target == startKey || seen[target] {
+			continue
+		}
+		seen[target] = true
+		parsed, _ := url.Parse(target)
+		if parsed.Host != start.Host || inbound[target] > 0 {
+			continue
+		}
+		orphans = append(orphans, target)
+	}
+	return orphans
+}
+
+// clamps the crawl depth and page budget to the allowed range
+func crawlLimits(maxDepth, maxPages int) (int, int) {
+	if maxDepth <= 0 {
+		maxDepth = analyzer.DefaultCrawlDepth
+	}
+	if maxDepth > analyzer.MaxCrawlDepth {
+		maxDepth = analyzer.MaxCrawlDepth
+	}
+	if maxPages <= 0 {
+		maxPages = analyzer.DefaultCrawlPages
+	}
+	if maxPages > analyzer.MaxCrawlPages {
+		maxPages = analyzer.MaxCrawlPages
+	}
+	return maxDepth, maxPages
+}
+
+func normalizeLinkURL(link string) string {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return normalizeCrawlURL(parsed)
+}
+
+// normalizes a URL so the same page is only crawled once.
+// the scheme and host are lowercased, default ports and fragments are dropped and an empty path becomes "/".
+// returns an empty string for non http(s) URLs
+func normalizeCrawlURL(u *url.URL) string {
+	scheme := strings.ToLower(u.Scheme)
+	if (scheme != "http" && scheme != "https") || u.Host == "" {
+		return ""
+	}
+
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port != "" && !(scheme == "http" && port == "80") && !(scheme == "https" && port == "443") {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	normalized := scheme + "://" + host + path
+	if u.RawQuery != "" {
+		normalized += "?" + u.RawQuery
+	}
+	return normalized
+}
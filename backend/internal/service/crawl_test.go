@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+	"webanalyzer/internal/log"
+	"webanalyzer/internal/model"
+)
+
+func TestCrawl(t *testing.T) {
+	log.Logger = zap.NewNop()
+	defer func(interval time.Duration) { hostRequestInterval = interval }(hostRequestInterval)
+	hostRequestInterval = time.Millisecond
+
+	pages := map[string]string{
+		"/":       `<title>Home</title><h1>Home</h1><a href="/a">A</a><a href="/b#top">B</a><a href="/missing">Missing</a><a href="/private">Private</a><a href="https://external.invalid/">Ext</a>`,
+		"/a":      `<title>A</title><h1>A</h1><h1>Again</h1><a href="/">Home</a><a href="/c">C</a><a href="/a">Self</a>`,
+		"/b":      `<h2>No title</h2><a href="/b">Self</a>`,
+		"/c":      `<title>C</title>`,
+		"/orphan": `<title>Orphan</title>`,
+	}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+			return
+		}
+		if r.URL.Path == "/sitemap.xml" {
+			fmt.Fprintf(w, `<urlset><url><loc>%[1]s/</loc></url><url><loc>%[1]s/a</loc></url><url><loc>%[1]s/private</loc></url><url><loc>%[1]s/orphan</loc></url><url><loc>https://external.invalid/orphan</loc></url></urlset>`, server.URL)
+			return
+		}
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, "<html><body>%s</body></html>", body)
+	}))
+	defer server.Close()
+
+	report, err := Crawl(context.Background(), server.URL, 1, 10, model.AnalyzeOptions{})
+	if err != nil {
+		t.Fatalf("Crawl() unexpected error: %v", err)
+	}
+
+	var crawled []string
+	for _, page := range report.Pages {
+		crawled = append(crawled, page.URL)
+		if page.Analysis == nil || page.Analysis.Links != nil {
+			t.Errorf("page %s analysis = %+v, want analysis without links", page.URL, page.Analysis)
+		}
+	}
+	want := []string{server.URL + "/", server.URL + "/a", server.URL + "/b"}
+	if !reflect.DeepEqual(crawled, want) {
+		t.Errorf("crawled pages = %v, want %v", crawled, want)
+	}
+
+	if report.PagesCrawled != 3 || report.MaxDepthReached != 1 || report.Truncated {
+		t.Errorf("report = %d pages, depth %d, truncated %v", report.PagesCrawled, report.MaxDepthReached, report.Truncated)
+	}
+	if !reflect.DeepEqual(report.PagesMissingTitle, []string{server.URL + "/b"}) {
+		t.Errorf("pages missing title = %v", report.PagesMissingTitle)
+	}
+	if !reflect.DeepEqual(report.PagesWithMultipleH1, []string{server.URL + "/a"}) {
+		t.Errorf("pages with multiple h1 = %v", report.PagesWithMultipleH1)
+	}
+	if report.HeadingTotals.H1 != 3 || report.HeadingTotals.H2 != 1 {
+		t.Errorf("heading totals = %+v", report.HeadingTotals)
+	}
+	if len(report.BrokenInternalLinks) != 1 || report.BrokenInternalLinks[0].TargetURL != server.URL+"/missing" || report.BrokenInternalLinks[0].StatusCode != http.StatusNotFound {
+		t.Errorf("broken internal links = %+v", report.BrokenInternalLinks)
+	}
+	if !reflect.DeepEqual(report.OrphanPages, []string{server.URL + "/orphan"}) {
+		t.Errorf("orphan pages = %v, want the unlinked sitemap URL", report.OrphanPages)
+	}
+
+	truncated, err := Crawl(context.Background(), server.URL, 3, 2, model.AnalyzeOptions{})
+	if err != nil {
+		t.Fatalf("Crawl() unexpected error: %v", err)
+	}
+	if len(truncated.Pages) != 2 || !truncated.Truncated {
+		t.Errorf("crawl with page budget 2 visited %d pages, truncated %v", len(truncated.Pages), truncated.Truncated)
+	}
+	if truncated.OrphanPages != nil {
+		t.Errorf("orphan pages of a truncated crawl = %v, want none", truncated.OrphanPages)
+	}
+}
+
+func TestNormalizeCrawlURL(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"HTTP://Example.COM", "http://example.com/"},
+		{"https://example.com:443/a?b=1#frag", "https://example.com/a?b=1"},
+		{"http://example.com:8080/a", "http://example.com:8080/a"},
+		{"mailto:test@example.com", ""},
+		{"ftp://example.com/file", ""},
+	}
+
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.input)
+		if got := normalizeCrawlURL(u); got != tt.expected {
+			t.Errorf("normalizeCrawlURL(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
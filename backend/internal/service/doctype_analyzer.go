@@ -0,0 +1,296 @@
+package service
+
+import (
+	"golang.org/x/net/html"
+	"mime"
+	"regexp"
+	"strings"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/util/analyzer"
+)
+
+const (
+	versionNoResponse = "No response (possibly blocked or restricted site)"
+	versionNoDoctype  = "Unknown (possibly HTML5 without explicit DOCTYPE)"
+	versionUnknown    = "Unknown"
+)
+
+// version and variant from the DTD name of a public identifier, e.g. "-//W3C//DTD XHTML 1.0 Strict//EN"
+var dtdName = regexp.MustCompile(`(?i)//DTD\s+(X?HTML(?:\s+Basic)?)\s+([\d.]+)(?:\s+(\w+))?\s*//`)
+
+// public identifiers (lowercase) that trigger quirks mode, from the HTML spec's initial insertion mode
+var quirksPublicIDs = map[string]bool{
+	"-//w3o//dtd w3 html strict 3.0//en//": true,
+	"-/w3c/dtd html 4.0 transitional/en":   true,
+	"html":                                 true,
+}
+
+const quirksSystemID = "http://www.ibm.com/data/dtd/v11/ibmxhtml1-transitional.dtd"
+
+var quirksPublicIDPrefixes = []string{
+	"+//silmaril//dtd html pro v0r11 19970101//",
+	"-//as//dtd html 3.0 aswedit + extensions//",
+	"-//advasoft ltd//dtd html 3.0 aswedit + extensions//",
+	"-//ietf//dtd html 2.0 level 1//",
+	"-//ietf//dtd html 2.0 level 2//",
+	"-//ietf//dtd html 2.0 strict level 1//",
+	"-//ietf//dtd html 2.0 strict level 2//",
+	"-//ietf//dtd html 2.0 strict//",
+	"-//ietf//dtd html 2.0//",
+	"-//ietf//dtd html 2.1e//",
+	"-//ietf//dtd html 3.0//",
+	"-//ietf//dtd html 3.2 final//",
+	"-//ietf//dtd html 3.2//",
+	"-//ietf//dtd html 3//",
+	"-//ietf//dtd html level 0//",
+	"-//ietf//dtd html level 1//",
+	"-//ietf//dtd html level 2//",
+	"-//ietf//dtd html level 3//",
+	"-//ietf//dtd html strict level 0//",
+	"-//ietf//dtd html strict level 1//",
+	"-//ietf//dtd html strict level 2//",
+	"-//ietf//dtd html strict level 3//",
+	"-//ietf//dtd html strict//",
+	"-//ietf//dtd html//",
+	"-//metrius//dtd metrius presentational//",
+	"-//microsoft//dtd internet explorer 2.0 html strict//",
+	"-//microsoft//dtd internet explorer 2.0 html//",
+	"-//microsoft//dtd internet explorer 2.0 tables//",
+	"-//microsoft//dtd internet explorer 3.0 html strict//",
+	"-//microsoft//dtd internet explorer 3.0 html//",
+	"-//microsoft//dtd internet explorer 3.0 tables//",
+	"-//netscape comm. corp.//dtd html//",
+	"-//netscape comm. corp.//dtd strict html//",
+	"-//o'reilly and associates//dtd html 2.0//",
+	"-//o'reilly and associates//dtd html extended 1.0//",
+	"-//o'reilly and associates//dtd html extended relaxed 1.0//",
+	"-//sq//dtd html 2.0 hotmetal + extensions//",
+	"-//softquad software//dtd hotmetal pro 6.0::19990601::extensions to html 4.0//",
+	"-//softquad//dtd hotmetal pro 4.0::19971010::extensions to html 4.0//",
+	"-//spyglass//dtd html 2.0 extended//",
+	"-//sun microsystems corp.//dtd hotjava html//",
+	"-//sun microsystems corp.//dtd hotjava strict html//",
+	"-//w3c//dtd html 3 1995-03-24//",
+	"-//w3c//dtd html 3.2 draft//",
+	"-//w3c//dtd html 3.2 final//",
+	"-//w3c//dtd html 3.2//",
+	"-//w3c//dtd html 3.2s draft//",
+	"-//w3c//dtd html 4.0 frameset//",
+	"-//w3c//dtd html 4.0 transitional//",
+	"-//w3c//dtd html experimental 19960712//",
+	"-//w3c//dtd html experimental 970421//",
+	"-//w3c//dtd w3 html//",
+	"-//w3o//dtd w3 html 3.0//",
+	"-//webtechs//dtd mozilla html 2.0//",
+	"-//webtechs//dtd mozilla html//",
+}
+
+// HTML 4.01 transitional and frameset give quirks mode without a system identifier
+// and almost standards mode with one
+var html401LoosePublicIDPrefixes = []string{
+	"-//w3c//dtd html 4.01 frameset//",
+	"-//w3c//dtd html 4.01 transitional//",
+}
+
+var almostStandardsPublicIDPrefixes = []string{
+	"-//w3c//dtd xhtml 1.0 frameset//",
+	"-//w3c//dtd xhtml 1.0 transitional//",
+}
+
+// doctypeDecl is the content of a <!DOCTYPE> token split into its parts
+type doctypeDecl struct {
+	name        string
+	publicID    string
+	systemID    string
+	hasPublicID bool
+	hasSystemID bool
+	forceQuirks bool
+}
+
+// read the doctype of the page and work out the rendering mode a browser picks for it
+func parseDoctype(rawHTML, contentType string) *model.Doctype {
+	doctype := &model.Doctype{ContentType: contentType, Warnings: []string{}}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		doctype.IsXHTML = mediaType == "application/xhtml+xml"
+	}
+
+	if rawHTML == "" {
+		doctype.Version = versionNoResponse
+		doctype.RenderingMode = analyzer.RenderingModeQuirks
+		return doctype
+	}
+
+	data, found := findDoctypeToken(rawHTML)
+	if !found {
+		doctype.Version = versionNoDoctype
+		if doctype.IsXHTML {
+			// XML documents are never rendered in quirks mode
+			doctype.RenderingMode = analyzer.RenderingModeStandards
+		} else {
+			doctype.RenderingMode = analyzer.RenderingModeQuirks
+			doctype.Warnings = append(doctype.Warnings, "page has no doctype, browsers render it in quirks mode")
+		}
+		return doctype
+	}
+
+	decl := parseDoctypeDecl(data)
+	doctype.Present = true
+	doctype.Name = decl.name
+	doctype.PublicID = decl.publicID
+	doctype.SystemID = decl.systemID
+	doctype.Version, doctype.Variant = doctypeVersion(decl)
+
+	if doctype.IsXHTML {
+		doctype.RenderingMode = analyzer.RenderingModeStandards
+	} else {
+		doctype.RenderingMode = renderingMode(decl)
+		if doctype.RenderingMode == analyzer.RenderingModeQuirks {
+			doctype.Warnings = append(doctype.Warnings, "doctype triggers quirks mode")
+		}
+		if strings.HasPrefix(doctype.Version, "XHTML") {
+			doctype.Warnings = append(doctype.Warnings, "XHTML doctype is not served as application/xhtml+xml, browsers parse the page as HTML")
+		}
+	}
+
+	return doctype
+}
+
+// return the content of the doctype token when it is the first thing in the document,
+// only comments and whitespace may come before it
+func findDoctypeToken(rawHTML string) (string, bool) {
+	tokenizer := html.NewTokenizer(strings.NewReader(rawHTML))
+	for {
+		switch tokenizer.Next() {
+		case html.DoctypeToken:
+			return string(tokenizer.Text()), true
+		case html.CommentToken:
+			continue
+		case html.TextToken:
+			if strings.TrimSpace(strings.TrimPrefix(string(tokenizer.Text()), "\ufeff")) == "" {
+				continue
+			}
+			return "", false
+		default:
+			return "", false
+		}
+	}
+}
+
+// split the doctype content into name, public and system identifier
+func parseDoctypeDecl(data string) doctypeDecl {
+	var decl doctypeDecl
+
+	data = strings.TrimLeft(data, " \t\n\f\r")
+	end := strings.IndexAny(data, " \t\n\f\r")
+	if end == -1 {
+		end = len(data)
+	}
+	decl.name = strings.ToLower(data[:end])
+	if decl.name == "" {
+		decl.forceQuirks = true
+		return decl
+	}
+	rest := strings.TrimLeft(data[end:], " \t\n\f\r")
+	if rest == "" {
+		return decl
+	}
+
+	if len(rest) < 6 {
+		decl.forceQuirks = true
+		return decl
+	}
+	keyword := strings.ToLower(rest[:6])
+	rest = rest[6:]
+	if keyword != "public" && keyword != "system" {
+		decl.forceQuirks = true
+		return decl
+	}
+
+	for keyword != "" {
+		rest = strings.TrimLeft(rest, " \t\n\f\r")
+		if rest == "" {
+			// the system identifier after a public identifier is optional
+			decl.forceQuirks = !decl.hasPublicID
+			break
+		}
+		quote := rest[0]
+		if quote != '"' && quote != '\'' {
+			decl.forceQuirks = true
+			break
+		}
+		rest = rest[1:]
+		id := rest
+		if i := strings.IndexByte(rest, quote); i != -1 {
+			id, rest = rest[:i], rest[i+1:]
+		} else {
+			// unterminated identifier
+			decl.forceQuirks = true
+			rest = ""
+		}
+
+		if keyword == "public" {
+			decl.publicID, decl.hasPublicID = id, true
+			keyword = "system"
+		} else {
+			decl.systemID, decl.hasSystemID = id, true
+			keyword = ""
+		}
+	}
+
+	return decl
+}
+
+// rendering mode for the doctype as decided by the HTML spec's initial insertion mode
+func renderingMode(decl doctypeDecl) string {
+	publicID := strings.ToLower(decl.publicID)
+	systemID := strings.ToLower(decl.systemID)
+
+	switch {
+	case decl.forceQuirks,
+		decl.name != "html",
+		quirksPublicIDs[publicID],
+		systemID == quirksSystemID,
+		hasAnyPrefix(publicID, quirksPublicIDPrefixes),
+		!decl.hasSystemID && hasAnyPrefix(publicID, html401LoosePublicIDPrefixes):
+		return analyzer.RenderingModeQuirks
+	case hasAnyPrefix(publicID, almostStandardsPublicIDPrefixes),
+		decl.hasSystemID && hasAnyPrefix(publicID, html401LoosePublicIDPrefixes):
+		return analyzer.RenderingModeAlmostStandards
+	default:
+		return analyzer.RenderingModeStandards
+	}
+}
+
+// HTML version and DTD variant (Strict, Transitional, Frameset) of the doctype
+func doctypeVersion(decl doctypeDecl) (string, string) {
+	if decl.name != "html" || decl.forceQuirks {
+		return versionUnknown, ""
+	}
+	if !decl.hasPublicID {
+		if !decl.hasSystemID || decl.systemID == "about:legacy-compat" {
+			return "HTML5", ""
+		}
+		return versionUnknown, ""
+	}
+
+	match := dtdName.FindStringSubmatch(decl.publicID)
+	if match == nil {
+		return versionUnknown, ""
+	}
+	version := strings.Join(strings.Fields(match[1]), " ") + " " + match[2]
+	variant := match[3]
+	if variant == "" && strings.HasPrefix(version, "HTML 4") {
+		// the HTML 4 strict DTD has no variant in its public identifier
+		variant = "Strict"
+	}
+	return version, variant
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
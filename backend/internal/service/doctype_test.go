@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"webanalyzer/internal/log"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/util/analyzer"
+)
+
+func TestParseDoctype(t *testing.T) {
+	tests := []struct {
+		name        string
+		rawHTML     string
+		contentType string
+		present     bool
+		publicID    string
+		systemID    string
+		version     string
+		variant     string
+		mode        string
+		isXHTML     bool
+		warnings    int
+	}{
+		{
+			name:    "HTML5",
+			rawHTML: "<!DOCTYPE html><html></html>",
+			present: true,
+			version: "HTML5",
+			mode:    analyzer.RenderingModeStandards,
+		},
+		{
+			name:    "HTML5 after comment and BOM",
+			rawHTML: "\ufeff<!-- generated -->\n<!doctype html><html></html>",
+			present: true,
+			version: "HTML5",
+			mode:    analyzer.RenderingModeStandards,
+		},
+		{
+			name:     "legacy compat",
+			rawHTML:  `<!DOCTYPE html SYSTEM "about:legacy-compat"><html></html>`,
+			present:  true,
+			systemID: "about:legacy-compat",
+			version:  "HTML5",
+			mode:     analyzer.RenderingModeStandards,
+		},
+		{
+			name:     "HTML 4.01 strict",
+			rawHTML:  `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01//EN" "http://www.w3.org/TR/html4/strict.dtd">`,
+			present:  true,
+			publicID: "-//W3C//DTD HTML 4.01//EN",
+			systemID: "http://www.w3.org/TR/html4/strict.dtd",
+			version:  "HTML 4.01",
+			variant:  "Strict",
+			mode:     analyzer.RenderingModeStandards,
+		},
+		{
+			name:     "HTML 4.01 transitional with system identifier",
+			rawHTML:  `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Transitional//EN" "http://www.w3.org/TR/html4/loose.dtd">`,
+			present:  true,
+			publicID: "-//W3C//DTD HTML 4.01 Transitional//EN",
+			systemID: "http://www.w3.org/TR/html4/loose.dtd",
+			version:  "HTML 4.01",
+			variant:  "Transitional",
+			mode:     analyzer.RenderingModeAlmostStandards,
+		},
+		{
+			name:     "HTML 4.01 transitional without system identifier",
+			rawHTML:  `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Transitional//EN">`,
+			present:  true,
+			publicID: "-//W3C//DTD HTML 4.01 Transitional//EN",
+			version:  "HTML 4.01",
+			variant:  "Transitional",
+			mode:     analyzer.RenderingModeQuirks,
+			warnings: 1,
+		},
+		{
+			name:     "HTML 3.2",
+			rawHTML:  `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 3.2 Final//EN">`,
+			present:  true,
+			publicID: "-//W3C//DTD HTML 3.2 Final//EN",
+			version:  "HTML 3.2",
+			variant:  "Final",
+			mode:     analyzer.RenderingModeQuirks,
+			warnings: 1,
+		},
+		{
+			name:     "XHTML 1.0 transitional served as HTML",
+			rawHTML:  `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">`,
+			present:  true,
+			publicID: "-//W3C//DTD XHTML 1.0 Transitional//EN",
+			systemID: "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd",
+			version:  "XHTML 1.0",
+			variant:  "Transitional",
+			mode:     analyzer.RenderingModeAlmostStandards,
+			warnings: 1,
+		},
+		{
+			name:        "XHTML 1.1 served as XHTML",
+			rawHTML:     `<!DOCTYPE html PUBLIC '-//W3C//DTD XHTML 1.1//EN' 'http://www.w3.org/TR/xhtml11/DTD/xhtml11.dtd'>`,
+			contentType: "application/xhtml+xml; charset=utf-8",
+			present:     true,
+			publicID:    "-//W3C//DTD XHTML 1.1//EN",
+			systemID:    "http://www.w3.org/TR/xhtml11/DTD/xhtml11.dtd",
+			version:     "XHTML 1.1",
+			mode:        analyzer.RenderingModeStandards,
+			isXHTML:     true,
+		},
+		{
+			name:     "not an html doctype",
+			rawHTML:  `<!DOCTYPE svg><svg></svg>`,
+			present:  true,
+			version:  "Unknown",
+			mode:     analyzer.RenderingModeQuirks,
+			warnings: 1,
+		},
+		{
+			name:     "missing public identifier",
+			rawHTML:  `<!DOCTYPE html PUBLIC><html></html>`,
+			present:  true,
+			version:  "Unknown",
+			mode:     analyzer.RenderingModeQuirks,
+			warnings: 1,
+		},
+		{
+			name:     "doctype after content",
+			rawHTML:  `<p>hi</p><!DOCTYPE html>`,
+			version:  "Unknown (possibly HTML5 without explicit DOCTYPE)",
+			mode:     analyzer.RenderingModeQuirks,
+			warnings: 1,
+		},
+		{
+			name:        "no doctype served as XHTML",
+			rawHTML:     `<html xmlns="http://www.w3.org/1999/xhtml"></html>`,
+			contentType: "application/xhtml+xml",
+			version:     "Unknown (possibly HTML5 without explicit DOCTYPE)",
+			mode:        analyzer.RenderingModeStandards,
+			isXHTML:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doctype := parseDoctype(tt.rawHTML, tt.contentType)
+			if doctype.Present != tt.present || doctype.PublicID != tt.publicID || doctype.SystemID != tt.systemID {
+				t.Errorf("parseDoctype() = %+v, want present %v, public %q, system %q", doctype, tt.present, tt.publicID, tt.systemID)
+			}
+			if doctype.Version != tt.version || doctype.Variant != tt.variant {
+				t.Errorf("parseDoctype() version = %q %q, want %q %q", doctype.Version, doctype.Variant, tt.version, tt.variant)
+			}
+			if doctype.RenderingMode != tt.mode {
+				t.Errorf("parseDoctype() rendering mode = %q, want %q", doctype.RenderingMode, tt.mode)
+			}
+			if doctype.IsXHTML != tt.isXHTML {
+				t.Errorf("parseDoctype() is XHTML = %v, want %v", doctype.IsXHTML, tt.isXHTML)
+			}
+			if len(doctype.Warnings) != tt.warnings {
+				t.Errorf("parseDoctype() warnings = %v, want %d", doctype.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestAnalyzePageDoctype(t *testing.T) {
+	log.Logger = zap.NewNop()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			w.Header().Set("Content-Type", "application/xhtml+xml")
+			fmt.Fprint(w, `<?xml version="1.0"?><!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Strict//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd"><html><head><title>X</title></head></html>`)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	page, err := AnalyzePage(context.Background(), server.URL, model.AnalyzeOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzePage() unexpected error: %v", err)
+	}
+	if page.HTMLVersion != "XHTML 1.0" || page.Doctype == nil {
+		t.Fatalf("AnalyzePage() html version = %q, doctype = %+v", page.HTMLVersion, page.Doctype)
+	}
+	if !page.Doctype.IsXHTML || page.Doctype.RenderingMode != analyzer.RenderingModeStandards || page.Doctype.Variant != "Strict" {
+		t.Errorf("AnalyzePage() doctype = %+v, want XHTML 1.0 Strict in standards mode", page.Doctype)
+	}
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"golang.org/x/net/html"
+	"golang.org/x/text/encoding/htmlindex"
+	"mime"
+	"strings"
+	"unicode/utf8"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/util/analyzer"
+)
+
+// byte order marks and the encodings they select
+var byteOrderMarks = []struct {
+	bom      []byte
+	encoding string
+}{
+	{bom: []byte{0xEF, 0xBB, 0xBF}, encoding: "utf-8"},
+	{bom: []byte{0xFE, 0xFF}, encoding: "utf-16be"},
+	{bom: []byte{0xFF, 0xFE}, encoding: "utf-16le"},
+}
+
+// determines the character encoding of body and transcodes it to UTF-8.
+// a BOM wins over the Content-Type charset, which wins over the <meta> prescan, as in the HTML spec
+func decodeBody(body []byte, contentType string) ([]byte, *model.Encoding) {
+	info := &model.Encoding{Warnings: []string{}}
+
+	for _, mark := range byteOrderMarks {
+		if bytes.HasPrefix(body, mark.bom) {
+			info.BOM = mark.encoding
+			body = body[len(mark.bom):]
+			break
+		}
+	}
+	info.Header = canonicalEncoding(info, headerCharset(contentType), "Content-Type header")
+	info.Meta = canonicalEncoding(info, prescanMetaCharset(body), "<meta> declaration")
+	// a page that can be prescanned is ASCII compatible, so a UTF-16 meta declaration cannot be right
+	switch info.Meta {
+	case "utf-16be", "utf-16le":
+		info.Meta = "utf-8"
+	case "x-user-defined":
+		info.Meta = "windows-1252"
+	}
+
+	switch {
+	case info.BOM != "":
+		info.Detected, info.Source = info.BOM, analyzer.EncodingSourceBOM
+	case info.Header != "":
+		info.Detected, info.Source = info.Header, analyzer.EncodingSourceHeader
+	case info.Meta != "":
+		info.Detected, info.Source = info.Meta, analyzer.EncodingSourceMeta
+	default:
+		info.Detected, info.Source = "windows-1252", analyzer.EncodingSourceDefault
+		if utf8.Valid(body) {
+			info.Detected = "utf-8"
+		}
+		info.Warnings = append(info.Warnings, fmt.Sprintf("page does not declare its character encoding, assumed %s", info.Detected))
+	}
+
+	declared := []string{}
+	for _, d := range []struct{ source, encoding string }{{"BOM", info.BOM}, {"header", info.Header}, {"meta", info.Meta}} {
+		if d.encoding == "" {
+			continue
+		}
+		if d.encoding != info.Detected {
+			info.Mismatch = true
+		}
+		declared = append(declared, d.source+" "+d.encoding)
+	}
+	if info.Mismatch {
+		info.Warnings = append(info.Warnings, fmt.Sprintf("declared encodings disagree (%s), decoded as %s", strings.Join(declared, ", "), info.Detected))
+	}
+
+	if info.Detected == "utf-8" {
+		return body, info
+	}
+	enc, err := htmlindex.Get(info.Detected)
+	if err != nil {
+		return body, info
+	}
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		info.Warnings = append(info.Warnings, fmt.Sprintf("failed to transcode from %s: %v", info.Detected, err))
+		return body, info
+	}
+	info.Transcoded = true
+	return decoded, info
+}
+
+// the WHATWG name of the encoding label, empty with a warning for unknown labels
+func canonicalEncoding(info *model.Encoding, label, where string) string {
+	if label == "" {
+		return ""
+	}
+	enc, err := htmlindex.Get(label)
+	if err != nil {
+		info.Warnings = append(info.Warnings, fmt.Sprintf("unknown charset %q in the %s", label, where))
+		return ""
+	}
+	name, _ := htmlindex.Name(enc)
+	return name
+}
+
+// the charset parameter of a Content-Type value
+func headerCharset(contentType string) string {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return params["charset"]
+}
+
+// looks for <meta charset> or <meta http-equiv="Content-Type"> in the first bytes of the page
+func prescanMetaCharset(body []byte) string {
+	z := html.NewTokenizer(bytes.NewReader(body[:min(len(body), analyzer.MetaPrescanBytes)]))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			if token.Data != "meta" {
+				continue
+			}
+			var httpEquiv, content string
+			for _, attr := range token.Attr {
+				switch strings.ToLower(attr.Key) {
+				case "charset":
+					if charset := strings.TrimSpace(attr.Val); charset != "" {
+						return charset
+					}
+				case "http-equiv":
+					httpEquiv = attr.Val
+				case "content":
+					content = attr.Val
+				}
+			}
+			if strings.EqualFold(strings.TrimSpace(httpEquiv), "content-type") {
+				if charset := charsetFromContent(content); charset != "" {
+					return charset
+				}
+			}
+		}
+	}
+}
+
+// extracts the charset from the content attribute of a http-equiv meta, e.g. text/html; charset="shift_jis"
+func charsetFromContent(content string) string {
+	lower := strings.ToLower(content)
+	i := strings.Index(lower, "charset")
+	if i < 0 {
+		return ""
+	}
+	rest := strings.TrimLeft(content[i+len("charset"):], " \t\n\f\r")
+	if !strings.HasPrefix(rest, "=") {
+		return ""
+	}
+	rest = strings.TrimLeft(rest[1:], " \t\n\f\r")
+	if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+		if end := strings.IndexByte(rest[1:], rest[0]); end >= 0 {
+			return rest[1 : end+1]
+		}
+		return ""
+	}
+	if end := strings.IndexAny(rest, " \t\n\f\r;"); end >= 0 {
+		rest = rest[:end]
+	}
+	return rest
+}
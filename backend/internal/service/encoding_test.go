@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"webanalyzer/internal/log"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/util/analyzer"
+)
+
+func encode(t *testing.T, enc encoding.Encoding, s string) []byte {
+	t.Helper()
+	b, err := enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDecodeBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		expected    string
+		encoding    model.Encoding
+		warnings    int
+	}{
+		{
+			name:        "Shift_JIS from the header",
+			body:        encode(t, japanese.ShiftJIS, "<title>日本語のページ</title>"),
+			contentType: "text/html; charset=Shift_JIS",
+			expected:    "<title>日本語のページ</title>",
+			encoding:    model.Encoding{Detected: "shift_jis", Source: analyzer.EncodingSourceHeader, Header: "shift_jis", Transcoded: true},
+		},
+		{
+			name:        "windows-1252 from meta charset",
+			body:        encode(t, charmap.Windows1252, `<meta charset="iso-8859-1"><title>Café – Menü</title>`),
+			contentType: "text/html",
+			expected:    `<meta charset="iso-8859-1"><title>Café – Menü</title>`,
+			encoding:    model.Encoding{Detected: "windows-1252", Source: analyzer.EncodingSourceMeta, Meta: "windows-1252", Transcoded: true},
+		},
+		{
+			name:        "GBK from http-equiv",
+			body:        encode(t, simplifiedchinese.GBK, `<meta http-equiv="Content-Type" content="text/html; charset='gb2312'"><title>中文网页</title>`),
+			contentType: "text/html",
+			expected:    `<meta http-equiv="Content-Type" content="text/html; charset='gb2312'"><title>中文网页</title>`,
+			encoding:    model.Encoding{Detected: "gbk", Source: analyzer.EncodingSourceMeta, Meta: "gbk", Transcoded: true},
+		},
+		{
+			name:        "UTF-16 BOM overrides the header",
+			body:        encode(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), "<title>Ünïcödé</title>"),
+			contentType: "text/html; charset=utf-8",
+			expected:    "<title>Ünïcödé</title>",
+			encoding:    model.Encoding{Detected: "utf-16le", Source: analyzer.EncodingSourceBOM, BOM: "utf-16le", Header: "utf-8", Transcoded: true, Mismatch: true},
+			warnings:    1,
+		},
+		{
+			name:        "header and meta disagree",
+			body:        []byte(`<meta charset="windows-1251"><title>plain</title>`),
+			contentType: "text/html; charset=UTF-8",
+			expected:    `<meta charset="windows-1251"><title>plain</title>`,
+			encoding:    model.Encoding{Detected: "utf-8", Source: analyzer.EncodingSourceHeader, Header: "utf-8", Meta: "windows-1251", Mismatch: true},
+			warnings:    1,
+		},
+		{
+			name:     "undeclared UTF-8 with a BOM",
+			body:     append([]byte{0xEF, 0xBB, 0xBF}, "<title>naïve</title>"...),
+			expected: "<title>naïve</title>",
+			encoding: model.Encoding{Detected: "utf-8", Source: analyzer.EncodingSourceBOM, BOM: "utf-8"},
+		},
+		{
+			name:        "undeclared non UTF-8 bytes",
+			body:        []byte("<title>caf\xe9</title>"),
+			contentType: "text/html",
+			expected:    "<title>café</title>",
+			encoding:    model.Encoding{Detected: "windows-1252", Source: analyzer.EncodingSourceDefault, Transcoded: true},
+			warnings:    1,
+		},
+		{
+			name:        "unknown charset label",
+			body:        []byte("<title>plain</title>"),
+			contentType: "text/html; charset=klingon",
+			expected:    "<title>plain</title>",
+			encoding:    model.Encoding{Detected: "utf-8", Source: analyzer.EncodingSourceDefault},
+			warnings:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, info := decodeBody(tt.body, tt.contentType)
+			if string(decoded) != tt.expected {
+				t.Errorf("decodeBody() = %q, want %q", decoded, tt.expected)
+			}
+			if len(info.Warnings) != tt.warnings {
+				t.Errorf("decodeBody() warnings = %q, want %d", info.Warnings, tt.warnings)
+			}
+			info.Warnings = nil
+			if !reflect.DeepEqual(*info, tt.encoding) {
+				t.Errorf("decodeBody() encoding = %+v, want %+v", *info, tt.encoding)
+			}
+		})
+	}
+}
+
+func TestAnalyzePageEncoding(t *testing.T) {
+	log.Logger = zap.NewNop()
+	page := encode(t, japanese.ShiftJIS, `<html><head><title>日本語のページ</title></head><body><h1>見出し</h1><a href="/a">リンク</a></body></html>`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=shift_jis")
+		w.Write(page)
+	}))
+	defer server.Close()
+
+	result, err := AnalyzePage(context.Background(), server.URL, model.AnalyzeOptions{IncludeLinks: true})
+	if err != nil {
+		t.Fatalf("AnalyzePage() unexpected error: %v", err)
+	}
+	if result.PageTitle != "日本語のページ" {
+		t.Errorf("title = %q, want 日本語のページ", result.PageTitle)
+	}
+	if len(result.Links) != 1 || result.Links[0].AnchorText != "リンク" {
+		t.Errorf("links = %+v, want the decoded anchor text", result.Links)
+	}
+	if result.Encoding == nil || result.Encoding.Detected != "shift_jis" || !result.Encoding.Transcoded {
+		t.Errorf("encoding = %+v, want transcoded shift_jis", result.Encoding)
+	}
+}
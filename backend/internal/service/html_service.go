@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/net/html"
+	"net/url"
+	"strings"
+	"webanalyzer/internal/model"
+)
+
+var ErrEmptyDocument = errors.New("HTML document is empty")
+
+// HTMLDocument is HTML supplied by the client, analyzed without fetching it
+type HTMLDocument struct {
+	HTML []byte
+	// ContentType is only used when it declares an HTML media type, for its charset
+	ContentType string
+	// BaseURL resolves the relative links and resources, without it they stay relative and are not checked
+	BaseURL string
+	// CheckLinks requests the links and social images of the document
+	CheckLinks bool
+}
+
+// AnalyzeHTML runs the extractors of AnalyzePage on a client supplied document.
+// there is no robots.txt, redirect, response header or TLS report since nothing is fetched
+func AnalyzeHTML(ctx context.Context, doc HTMLDocument, opts model.AnalyzeOptions) (*model.WebpageAnalysis, error) {
+	page := &model.WebpageAnalysis{}
+	emit, emitStage := newEmitter(page, nil)
+
+	if len(doc.HTML) == 0 {
+		return page, ErrEmptyDocument
+	}
+	if err := ValidateAnalyzeOptions(opts); err != nil {
+		return page, err
+	}
+	baseURL, err := url.Parse(doc.BaseURL)
+	if err != nil {
+		return page, fmt.Errorf("invalid base URL: %w", err)
+	}
+
+	contentType := doc.ContentType
+	if !htmlMediaTypes[parseMediaType(contentType)] {
+		contentType = "text/html"
+	}
+	page.Content = &model.Content{
+		ContentType:   contentType,
+		MediaType:     parseMediaType(contentType),
+		IsHTML:        true,
+		TransferBytes: int64(len(doc.HTML)),
+		BodyBytes:     len(doc.HTML),
+		Warnings:      []string{},
+	}
+
+	decoded, encoding := decodeBody(doc.HTML, contentType)
+	page.Encoding = encoding
+	rawHTML := string(decoded)
+	root, err := html.Parse(strings.NewReader(rawHTML))
+	if err != nil {
+		return page, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	parsed := &fetchedPage{
+		root:        root,
+		rawHTML:     rawHTML,
+		contentType: contentType,
+		requestURL:  baseURL,
+		finalURL:    baseURL,
+		content:     page.Content,
+		encoding:    encoding,
+	}
+	return analyzeDocument(ctx, page, parsed, baseURL, opts, doc.CheckLinks, emit, emitStage)
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"golang.org/x/text/encoding/charmap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"webanalyzer/internal/log"
+	"webanalyzer/internal/model"
+)
+
+func TestAnalyzeHTML(t *testing.T) {
+	log.Logger = zap.NewNop()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	page := []byte(`<html><head><title>Uploaded</title></head><body><h1>Heading</h1><a href="/ok">ok</a><a href="/missing">missing</a><a href="https://example.com/">external</a></body></html>`)
+
+	tests := []struct {
+		name         string
+		doc          HTMLDocument
+		title        string
+		internal     int
+		external     int
+		inaccessible int
+		checked      bool
+		resolved     string
+	}{
+		{
+			name:     "relative links without a base URL",
+			doc:      HTMLDocument{HTML: page},
+			title:    "Uploaded",
+			internal: 2,
+			external: 1,
+			resolved: "/ok",
+		},
+		{
+			name:     "base URL without checking the links",
+			doc:      HTMLDocument{HTML: page, BaseURL: server.URL},
+			title:    "Uploaded",
+			internal: 2,
+			external: 1,
+			resolved: server.URL + "/ok",
+		},
+		{
+			name:         "base URL and link check",
+			doc:          HTMLDocument{HTML: []byte(`<title>Checked</title><a href="/ok">ok</a><a href="/missing">missing</a>`), BaseURL: server.URL, CheckLinks: true},
+			title:        "Checked",
+			internal:     2,
+			inaccessible: 1,
+			checked:      true,
+			resolved:     server.URL + "/ok",
+		},
+		{
+			name:  "charset from a meta declaration",
+			doc:   HTMLDocument{HTML: append([]byte(`<meta charset="iso-8859-1">`), encode(t, charmap.Windows1252, "<title>Café</title>")...)},
+			title: "Café",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := AnalyzeHTML(context.Background(), tt.doc, model.AnalyzeOptions{IncludeLinks: true})
+			if err != nil {
+				t.Fatalf("AnalyzeHTML() unexpected error: %v", err)
+			}
+			if result.PageTitle != tt.title {
+				t.Errorf("title = %q, want %q", result.PageTitle, tt.title)
+			}
+			if result.InternalLinkCount != tt.internal || result.ExternalLinkCount != tt.external || result.InaccessibleLinkCount != tt.inaccessible {
+				t.Errorf("links internal/external/inaccessible = %d/%d/%d, want %d/%d/%d", result.InternalLinkCount, result.ExternalLinkCount,
+					result.InaccessibleLinkCount, tt.internal, tt.external, tt.inaccessible)
+			}
+			for _, link := range result.Links {
+				if link.Skipped == tt.checked {
+					t.Errorf("link %s skipped = %v, want %v", link.Href, link.Skipped, !tt.checked)
+				}
+			}
+			if tt.resolved != "" && (len(result.Links) == 0 || result.Links[0].ResolvedURL != tt.resolved) {
+				t.Errorf("links = %+v, want the first resolved to %s", result.Links, tt.resolved)
+			}
+			if result.Robots != nil || result.Redirects != nil {
+				t.Errorf("robots = %+v, redirects = %+v, want none for a document that was not fetched", result.Robots, result.Redirects)
+			}
+		})
+	}
+}
+
+func TestAnalyzeHTMLEmptyDocument(t *testing.T) {
+	if _, err := AnalyzeHTML(context.Background(), HTMLDocument{}, model.AnalyzeOptions{}); !errors.Is(err, ErrEmptyDocument) {
+		t.Errorf("AnalyzeHTML() error = %v, want %v", err, ErrEmptyDocument)
+	}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"golang.org/x/time/rate"
+	"strings"
+	"sync"
+	"time"
+	"webanalyzer/internal/util/analyzer"
+)
+
+// hostRequestInterval is the minimum delay between two batch or crawl requests to the same host
+var hostRequestInterval = analyzer.HostRequestInterval
+
+// hostLimiter spaces out requests to the same host so batch and crawl runs stay polite
+type hostLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	limiters map[string]*rate.Limiter
+}
+
+func newHostLimiter(interval time.Duration) *hostLimiter {
+	return &hostLimiter{
+		interval: interval,
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+// Wait blocks until a request to host is allowed or ctx is done
+func (h *hostLimiter) Wait(ctx context.Context, host string) error {
+	return h.limiter(host).Wait(ctx)
+}
+
+// SetMinInterval makes requests to host at least interval apart, it never shortens the default interval
+func (h *hostLimiter) SetMinInterval(host string, interval time.Duration) {
+	if interval <= h.interval {
+		return
+	}
+	l := h.limiter(host)
+	if l.Limit() > rate.Every(interval) {
+		l.SetLimit(rate.Every(interval))
+	}
+}
+
+func (h *hostLimiter) limiter(host string) *rate.Limiter {
+	host = strings.ToLower(host)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	l, ok := h.limiters[host]
+	if !ok {
+		l = rate.NewLimiter(rate.Every(h.interval), 1)
+		h.limiters[host] = l
+	}
+	return l
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/util/analyzer"
+)
+
+var (
+	ErrRedirectLoop     = errors.New("redirect loop detected")
+	ErrTooManyRedirects = errors.New("too many redirects")
+)
+
+// redirectRecorder is the CheckRedirect of the page fetch client, it records every hop and stops loops
+type redirectRecorder struct {
+	scope   *url.URL
+	opts    model.AnalyzeOptions
+	visited map[string]bool
+	hops    []model.RedirectHop
+	lastHop time.Time
+}
+
+// the custom request options of opts are dropped from redirects that leave the host of targetURL
+func newRedirectRecorder(targetURL string, opts model.AnalyzeOptions) *redirectRecorder {
+	scope, _ := url.Parse(targetURL)
+	if scope == nil {
+		scope = &url.URL{}
+	}
+	return &redirectRecorder{
+		scope:   scope,
+		opts:    opts,
+		visited: map[string]bool{targetURL: true},
+		hops:    []model.RedirectHop{},
+		lastHop: time.Now(),
+	}
+}
+
+func (r *redirectRecorder) checkRedirect(req *http.Request, via []*http.Request) error {
+	now := time.Now()
+	hop := model.RedirectHop{
+		URL:        via[len(via)-1].URL.String(),
+		Target:     req.URL.String(),
+		DurationMs: now.Sub(r.lastHop).Milliseconds(),
+	}
+	if req.Response != nil {
+		hop.StatusCode = req.Response.StatusCode
+		hop.Location = req.Response.Header.Get("Location")
+	}
+	r.hops = append(r.hops, hop)
+	r.lastHop = now
+
+	if r.visited[hop.Target] {
+		return fmt.Errorf("%w: %s", ErrRedirectLoop, r.path())
+	}
+	r.visited[hop.Target] = true
+	if len(r.hops) > analyzer.MaxRedirects {
+		return fmt.Errorf("%w: stopped after %d redirects", ErrTooManyRedirects, analyzer.MaxRedirects)
+	}
+	scopeRedirect(req, r.scope, r.opts)
+	return nil
+}
+
+// the followed URLs joined with arrows
+func (r *redirectRecorder) path() string {
+	urls := []string{r.hops[0].URL}
+	for _, hop := range r.hops {
+		urls = append(urls, hop.Target)
+	}
+	return strings.Join(urls, " -> ")
+}
+
+// builds the reported chain once the page has been reached at finalURL
+func (r *redirectRecorder) chain(finalURL *url.URL) *model.RedirectChain {
+	chain := &model.RedirectChain{
+		Hops:     r.hops,
+		FinalURL: finalURL.String(),
+		Warnings: []string{},
+	}
+	for _, hop := range r.hops {
+		if strings.HasPrefix(hop.URL, "https://") && strings.HasPrefix(hop.Target, "http://") {
+			chain.Downgrade = true
+			chain.Warnings = append(chain.Warnings, fmt.Sprintf("redirect from %s downgrades HTTPS to HTTP", hop.URL))
+		}
+	}
+	if len(r.hops) > 1 {
+		chain.Warnings = append(chain.Warnings, fmt.Sprintf("page is reached after %d redirects, link to the final URL directly", len(r.hops)))
+	}
+	return chain
+}
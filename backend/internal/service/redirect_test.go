@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"webanalyzer/internal/log"
+	"webanalyzer/internal/model"
+)
+
+func TestAnalyzePageRedirects(t *testing.T) {
+	log.Logger = zap.NewNop()
+	final := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/new/":
+			fmt.Fprint(w, `<html><body><a href="page">Page</a></body></html>`)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer final.Close()
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/middle", http.StatusMovedPermanently)
+		case "/middle":
+			http.Redirect(w, r, final.URL+"/new/", http.StatusFound)
+		case "/loop-a":
+			http.Redirect(w, r, "/loop-b", http.StatusFound)
+		case "/loop-b":
+			http.Redirect(w, r, "/loop-a", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer origin.Close()
+
+	page, err := AnalyzePage(context.Background(), origin.URL+"/old", model.AnalyzeOptions{IncludeLinks: true})
+	if err != nil {
+		t.Fatalf("AnalyzePage() unexpected error: %v", err)
+	}
+
+	chain := page.Redirects
+	if chain == nil || len(chain.Hops) != 2 || chain.FinalURL != final.URL+"/new/" {
+		t.Fatalf("redirects = %+v, want 2 hops ending at %s/new/", chain, final.URL)
+	}
+	expected := []model.RedirectHop{
+		{URL: origin.URL + "/old", StatusCode: http.StatusMovedPermanently, Location: "/middle", Target: origin.URL + "/middle"},
+		{URL: origin.URL + "/middle", StatusCode: http.StatusFound, Location: final.URL + "/new/", Target: final.URL + "/new/"},
+	}
+	for i, want := range expected {
+		hop := chain.Hops[i]
+		hop.DurationMs = 0
+		if hop != want {
+			t.Errorf("hop %d = %+v, want %+v", i, hop, want)
+		}
+	}
+	if chain.Downgrade || len(chain.Warnings) != 1 {
+		t.Errorf("redirects = %+v, want a single chain length warning", chain)
+	}
+
+	// the link is resolved against and compared with the final URL, not the requested one
+	if link := page.Links[0]; link.ResolvedURL != final.URL+"/new/page" || !link.IsInternal || !link.IsAccessible {
+		t.Errorf("link = %+v, want internal link on the final host", link)
+	}
+
+	_, err = AnalyzePage(context.Background(), origin.URL+"/loop-a", model.AnalyzeOptions{})
+	if !errors.Is(err, ErrRedirectLoop) {
+		t.Errorf("AnalyzePage() error = %v, want %v", err, ErrRedirectLoop)
+	}
+}
+
+func TestRedirectChainDowngrade(t *testing.T) {
+	recorder := newRedirectRecorder("https://example.com/", model.AnalyzeOptions{})
+	recorder.hops = append(recorder.hops, model.RedirectHop{URL: "https://example.com/", StatusCode: http.StatusFound, Target: "http://example.com/"})
+	finalURL, _ := url.Parse("http://example.com/")
+
+	chain := recorder.chain(finalURL)
+	if !chain.Downgrade || len(chain.Warnings) != 1 {
+		t.Errorf("chain = %+v, want a downgrade warning", chain)
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/http/httpguts"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/util/analyzer"
+)
+
+var ErrInvalidRequestOptions = errors.New("invalid request options")
+
+// headers that control the connection or the message framing, callers can never set them
+var forbiddenRequestHeaders = map[string]bool{
+	"Host":                true,
+	"Connection":          true,
+	"Content-Length":      true,
+	"Transfer-Encoding":   true,
+	"Upgrade":             true,
+	"Te":                  true,
+	"Trailer":             true,
+	"Keep-Alive":          true,
+	"Proxy-Authorization": true,
+	"Proxy-Connection":    true,
+}
+
+var (
+	allowedHeadersMu      sync.RWMutex
+	allowedRequestHeaders = headerSet(analyzer.DefaultAllowedRequestHeaders)
+)
+
+// SetAllowedRequestHeaders replaces the headers callers may send to the analyzed host
+func SetAllowedRequestHeaders(names []string) {
+	allowedHeadersMu.Lock()
+	defer allowedHeadersMu.Unlock()
+	allowedRequestHeaders = headerSet(names)
+}
+
+func headerSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			set[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+	return set
+}
+
+// ValidateAnalyzeOptions checks the custom user agent, headers and cookies of the options
+func ValidateAnalyzeOptions(opts model.AnalyzeOptions) error {
+	if opts.UserAgent != "" && !httpguts.ValidHeaderFieldValue(opts.UserAgent) {
+		return fmt.Errorf("%w: invalid user agent", ErrInvalidRequestOptions)
+	}
+
+	allowedHeadersMu.RLock()
+	defer allowedHeadersMu.RUnlock()
+	for name, value := range opts.Headers {
+		canonical := http.CanonicalHeaderKey(name)
+		if !httpguts.ValidHeaderFieldName(name) || !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("%w: invalid header %q", ErrInvalidRequestOptions, name)
+		}
+		if forbiddenRequestHeaders[canonical] || !allowedRequestHeaders[canonical] {
+			return fmt.Errorf("%w: header %q is not allowed", ErrInvalidRequestOptions, canonical)
+		}
+	}
+
+	for name, value := range opts.Cookies {
+		if err := (&http.Cookie{Name: name, Value: value}).Valid(); err != nil {
+			return fmt.Errorf("%w: invalid cookie %q", ErrInvalidRequestOptions, name)
+		}
+	}
+	return nil
+}
+
+// sets the user agent of the request, the custom user agent, headers and cookies are only sent to the host of scope,
+// the URL the caller asked to analyze, never to the hosts it redirects to
+func applyRequestOptions(req *http.Request, scope *url.URL, opts model.AnalyzeOptions) {
+	req.Header.Set("User-Agent", analyzer.UserAgent)
+	if !strings.EqualFold(req.URL.Host, scope.Host) {
+		return
+	}
+
+	if opts.UserAgent != "" {
+		req.Header.Set("User-Agent", opts.UserAgent)
+	}
+	for name, value := range opts.Headers {
+		req.Header.Set(name, value)
+	}
+	for name, value := range opts.Cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+}
+
+// drops the custom request options from a redirect that leaves the host of scope.
+// the client copies every header to the redirect and only drops Authorization and Cookie for other domains
+func scopeRedirect(req *http.Request, scope *url.URL, opts model.AnalyzeOptions) {
+	if strings.EqualFold(req.URL.Host, scope.Host) {
+		return
+	}
+	req.Header.Set("User-Agent", analyzer.UserAgent)
+	for name := range opts.Headers {
+		req.Header.Del(name)
+	}
+	if len(opts.Cookies) > 0 {
+		req.Header.Del("Cookie")
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"webanalyzer/internal/log"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/util/analyzer"
+)
+
+func TestValidateAnalyzeOptions(t *testing.T) {
+	defer SetAllowedRequestHeaders(analyzer.DefaultAllowedRequestHeaders)
+	SetAllowedRequestHeaders([]string{"authorization", " X-Staging-Token ", "Host"})
+
+	tests := []struct {
+		name    string
+		opts    model.AnalyzeOptions
+		wantErr bool
+	}{
+		{
+			name: "allowed headers and cookies",
+			opts: model.AnalyzeOptions{
+				UserAgent: "StagingBot/2.0",
+				Headers:   model.RequestValues{"Authorization": "Bearer abc", "x-staging-token": "t0k3n"},
+				Cookies:   model.RequestValues{"session": "s3cr3t"},
+			},
+		},
+		{
+			name:    "header not in the allow-list",
+			opts:    model.AnalyzeOptions{Headers: model.RequestValues{"X-Forwarded-For": "10.0.0.1"}},
+			wantErr: true,
+		},
+		{
+			name:    "forbidden header even when allowed",
+			opts:    model.AnalyzeOptions{Headers: model.RequestValues{"Host": "internal.example.com"}},
+			wantErr: true,
+		},
+		{
+			name:    "header value with a line break",
+			opts:    model.AnalyzeOptions{Headers: model.RequestValues{"Authorization": "Bearer abc\r\nX-Injected: 1"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid cookie name",
+			opts:    model.AnalyzeOptions{Cookies: model.RequestValues{"bad name": "value"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid user agent",
+			opts:    model.AnalyzeOptions{UserAgent: "Bot\n"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAnalyzeOptions(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAnalyzeOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidRequestOptions) {
+				t.Errorf("ValidateAnalyzeOptions() error = %v, want %v", err, ErrInvalidRequestOptions)
+			}
+		})
+	}
+}
+
+func TestAnalyzePageRequestOptions(t *testing.T) {
+	var mu sync.Mutex
+	received := make(map[string]*http.Request)
+	record := func(name string, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		received[name] = r
+	}
+
+	external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		record("external", r)
+	}))
+	defer external.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			record("page", r)
+			fmt.Fprintf(w, `<html><body><a href="/internal">Internal</a><a href="%s/external">External</a></body></html>`, external.URL)
+		case "/internal":
+			record("internal", r)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	log.Logger = zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&logs), zapcore.DebugLevel))
+	defer func() { log.Logger = zap.NewNop() }()
+
+	opts := model.AnalyzeOptions{
+		UserAgent: "StagingBot/2.0",
+		Headers:   model.RequestValues{"Authorization": "Bearer s3cr3t"},
+		Cookies:   model.RequestValues{"session": "c00kie"},
+	}
+	if _, err := AnalyzePage(context.Background(), server.URL, opts); err != nil {
+		t.Fatalf("AnalyzePage() unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"page", "internal"} {
+		r := received[name]
+		if r == nil {
+			t.Fatalf("%s request was not sent", name)
+		}
+		if r.UserAgent() != "StagingBot/2.0" || r.Header.Get("Authorization") != "Bearer s3cr3t" {
+			t.Errorf("%s request headers = %v, want custom user agent and authorization", name, r.Header)
+		}
+		if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "c00kie" {
+			t.Errorf("%s request cookie = %v, want session cookie", name, cookie)
+		}
+	}
+
+	r := received["external"]
+	if r == nil {
+		t.Fatal("external request was not sent")
+	}
+	if r.UserAgent() != analyzer.UserAgent || r.Header.Get("Authorization") != "" || len(r.Cookies()) != 0 {
+		t.Errorf("external request headers = %v, want none of the custom options", r.Header)
+	}
+
+	if strings.Contains(logs.String(), "s3cr3t") || strings.Contains(logs.String(), "c00kie") {
+		t.Errorf("logs contain credentials: %s", logs.String())
+	}
+	if !strings.Contains(logs.String(), model.RedactedValue) {
+		t.Errorf("logs = %s, want redacted custom options", logs.String())
+	}
+}
+
+func TestAnalyzePageCrossHostRedirect(t *testing.T) {
+	log.Logger = zap.NewNop()
+	defer SetAllowedRequestHeaders(analyzer.DefaultAllowedRequestHeaders)
+	SetAllowedRequestHeaders([]string{"Authorization", "X-Staging-Token"})
+
+	var mu sync.Mutex
+	var leaked []string
+	var originRequests int
+	var origin *httptest.Server
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if r.Header.Get("Authorization") != "" || r.Header.Get("X-Staging-Token") != "" || r.Header.Get("Cookie") != "" || r.UserAgent() != analyzer.UserAgent {
+			leaked = append(leaked, r.URL.Path)
+		}
+		mu.Unlock()
+		if r.URL.Path == "/" {
+			fmt.Fprintf(w, `<html><head><meta property="og:image" content="/og.png"></head><body><a href="/login">Login</a><a href="%[1]s/back">Back</a><a href="%[1]s/hop">Hop</a></body></html>`, origin.URL)
+		}
+	}))
+	defer other.Close()
+	origin = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			http.Redirect(w, r, other.URL+"/", http.StatusFound)
+		case "/hop":
+			http.Redirect(w, r, other.URL+"/hopped", http.StatusFound)
+		case "/back":
+			mu.Lock()
+			defer mu.Unlock()
+			if r.Header.Get("X-Staging-Token") == "t0k3n" {
+				originRequests++
+			}
+		}
+	}))
+	defer origin.Close()
+
+	opts := model.AnalyzeOptions{
+		IncludeLinks: true,
+		UserAgent:    "StagingBot/2.0",
+		Headers:      model.RequestValues{"Authorization": "Bearer s3cr3t", "X-Staging-Token": "t0k3n"},
+		Cookies:      model.RequestValues{"session": "c00kie"},
+	}
+	page, err := AnalyzePage(context.Background(), origin.URL, opts)
+	if err != nil {
+		t.Fatalf("AnalyzePage() unexpected error: %v", err)
+	}
+	if len(page.Links) != 3 {
+		t.Fatalf("links = %+v, want the 3 links of the redirected page", page.Links)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(leaked) != 0 {
+		t.Errorf("custom request options were sent to the redirect host for %v", leaked)
+	}
+	if originRequests == 0 {
+		t.Error("link back to the requested host was checked without the custom request options")
+	}
+}
+
+func TestRequestValuesRedaction(t *testing.T) {
+	job := model.AnalysisJob{Options: model.AnalyzeOptions{
+		Headers: model.RequestValues{"Authorization": "Bearer s3cr3t"},
+		Cookies: model.RequestValues{"session": "c00kie"},
+	}}
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	if strings.Contains(string(data), "s3cr3t") || strings.Contains(string(data), "c00kie") {
+		t.Errorf("serialized job = %s, want redacted values", data)
+	}
+
+	var opts model.AnalyzeOptions
+	if err := json.Unmarshal([]byte(`{"headers":{"Authorization":"Bearer abc"}}`), &opts); err != nil || opts.Headers["Authorization"] != "Bearer abc" {
+		t.Errorf("json.Unmarshal() = %+v, %v, want the header value", opts, err)
+	}
+}
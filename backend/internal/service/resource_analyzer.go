@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"golang.org/x/net/html"
+	"golang.org/x/net/publicsuffix"
+	"net/url"
+	"strings"
+	"sync"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/util/analyzer"
+)
+
+// resource type of a <link rel="preload"> by its "as" attribute
+var preloadTypes = map[string]string{
+	"script": analyzer.ResourceScript,
+	"style":  analyzer.ResourceStylesheet,
+	"font":   analyzer.ResourceFont,
+	"image":  analyzer.ResourceImage,
+	"audio":  analyzer.ResourceMedia,
+	"video":  analyzer.ResourceMedia,
+}
+
+// collects every subresource referenced by the page, each resolved URL is reported once per type
+func extractResources(root *html.Node, baseURL *url.URL) []model.Resource {
+	resources := []model.Resource{}
+	seen := make(map[string]bool)
+
+	add := func(node *html.Node, attribute, value, resourceType string) {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return
+		}
+		parsed, err := url.Parse(value)
+		if err != nil {
+			return
+		}
+		resolved := baseURL.ResolveReference(parsed)
+		// data:, blob: and javascript: URLs do not cause a request
+		if resolved.Scheme != "http" && resolved.Scheme != "https" {
+			return
+		}
+		resolved.Fragment = ""
+		key := resourceType + " " + resolved.String()
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		resources = append(resources, model.Resource{
+			Type:         resourceType,
+			Tag:          node.Data,
+			Attribute:    attribute,
+			URL:          value,
+			ResolvedURL:  resolved.String(),
+			IsThirdParty: isThirdParty(resolved, baseURL),
+		})
+	}
+	addSrcset := func(node *html.Node) {
+		for _, candidate := range parseSrcset(analyzer.GetAttr(node, "srcset")) {
+			add(node, "srcset", candidate, analyzer.ResourceImage)
+		}
+	}
+
+	var visit func(*html.Node)
+	visit = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Namespace == "" {
+			switch node.Data {
+			case "script":
+				add(node, "src", analyzer.GetAttr(node, "src"), analyzer.ResourceScript)
+			case "link":
+				if resourceType := linkResourceType(node); resourceType != "" {
+					add(node, "href", analyzer.GetAttr(node, "href"), resourceType)
+				}
+			case "img":
+				add(node, "src", analyzer.GetAttr(node, "src"), analyzer.ResourceImage)
+				addSrcset(node)
+			case "source":
+				// <source src> only exists in <video> and <audio>, <source srcset> in <picture>
+				add(node, "src", analyzer.GetAttr(node, "src"), analyzer.ResourceMedia)
+				addSrcset(node)
+			case "video":
+				add(node, "src", analyzer.GetAttr(node, "src"), analyzer.ResourceMedia)
+				add(node, "poster", analyzer.GetAttr(node, "poster"), analyzer.ResourceImage)
+			case "audio":
+				add(node, "src", analyzer.GetAttr(node, "src"), analyzer.ResourceMedia)
+			case "iframe":
+				add(node, "src", analyzer.GetAttr(node, "src"), analyzer.ResourceIframe)
+			case "object":
+				add(node, "data", analyzer.GetAttr(node, "data"), analyzer.ResourceObject)
+			}
+		}
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(root)
+
+	return resources
+}
+
+// resource type of a <link> element, empty when the link does not load a subresource
+func linkResourceType(node *html.Node) string {
+	rel := strings.Fields(strings.ToLower(analyzer.GetAttr(node, "rel")))
+	switch {
+	case containsToken(rel, "stylesheet"):
+		return analyzer.ResourceStylesheet
+	case containsToken(rel, "icon"), containsToken(rel, "apple-touch-icon"):
+		return analyzer.ResourceIcon
+	case containsToken(rel, "modulepreload"):
+		return analyzer.ResourceScript
+	case containsToken(rel, "preload"):
+		if resourceType, ok := preloadTypes[strings.ToLower(analyzer.GetAttr(node, "as"))]; ok {
+			return resourceType
+		}
+		return analyzer.ResourcePreload
+	default:
+		return ""
+	}
+}
+
+// returns the URLs of a srcset attribute, dropping the width and density descriptors
+func parseSrcset(srcset string) []string {
+	var urls []string
+	for {
+		srcset = strings.TrimLeft(srcset, " \t\n\f\r,")
+		if srcset == "" {
+			return urls
+		}
+		end := strings.IndexAny(srcset, " \t\n\f\r")
+		if end == -1 {
+			end = len(srcset)
+		}
+		candidate := srcset[:end]
+		srcset = srcset[end:]
+
+		if strings.HasSuffix(candidate, ",") {
+			// a candidate without descriptors
+			candidate = strings.TrimRight(candidate, ",")
+		} else if comma := strings.IndexByte(srcset, ','); comma != -1 {
+			srcset = srcset[comma+1:]
+		} else {
+			srcset = ""
+		}
+		urls = append(urls, candidate)
+	}
+}
+
+// a resource is third party when it is not served from the registrable domain of the page
+func isThirdParty(u, baseURL *url.URL) bool {
+	host, baseHost := strings.ToLower(u.Hostname()), strings.ToLower(baseURL.Hostname())
+	if host == baseHost {
+		return false
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return true
+	}
+	baseDomain, err := publicsuffix.EffectiveTLDPlusOne(baseHost)
+	if err != nil {
+		return true
+	}
+	return domain != baseDomain
+}
+
+// checks every resource with a HEAD request (GET as fallback) and records its status and Content-Length
+func checkResources(ctx context.Context, check *linkCheck, resources []model.Resource, baseURL *url.URL, opts model.AnalyzeOptions) {
+	if len(resources) == 0 {
+		return
+	}
+
+	resourceJobs := make(chan int, len(resources))
+
+	numWorkers := analyzer.MaxLinkCheckWorkers
+	if len(resources) < numWorkers {
+		numWorkers = len(resources)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range resourceJobs {
+				resource := &resources[idx]
+				var result analyzer.LinkResult
+				select {
+				case <-ctx.Done():
+					result = analyzer.LinkResult{ErrorClass: analyzer.LinkErrorTimeout}
+				default:
+					result = checkLinkAccessibility(ctx, check, resource.ResolvedURL, baseURL, opts)
+				}
+				resource.IsAccessible = result.IsAccessible && !result.Skipped
+				resource.StatusCode = result.StatusCode
+				resource.ErrorClass = result.ErrorClass
+				if resource.IsAccessible && result.ContentLength > 0 {
+					resource.SizeBytes = result.ContentLength
+				}
+			}
+		}()
+	}
+
+	for i := range resources {
+		resourceJobs <- i
+	}
+	close(resourceJobs)
+
+	wg.Wait()
+}
+
+// counts the resources and adds up the page weight, a resource URL referenced more than once is counted once
+func summarizeResources(resources []model.Resource, htmlBytes int, checked bool) *model.Resources {
+	summary := &model.Resources{
+		Items:        resources,
+		CountsByType: make(map[string]int),
+		Checked:      checked,
+		HTMLBytes:    int64(htmlBytes),
+	}
+
+	sized := make(map[string]bool)
+	for _, resource := range resources {
+		summary.CountsByType[resource.Type]++
+		if resource.IsThirdParty {
+			summary.ThirdPartyCount++
+		} else {
+			summary.FirstPartyCount++
+		}
+
+		if !checked || sized[resource.ResolvedURL] {
+			continue
+		}
+		sized[resource.ResolvedURL] = true
+		if resource.SizeBytes > 0 {
+			summary.ResourceBytes += resource.SizeBytes
+		} else {
+			summary.UnknownSizeCount++
+		}
+	}
+	summary.TotalBytes = summary.HTMLBytes + summary.ResourceBytes
+
+	return summary
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"golang.org/x/net/html"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"webanalyzer/internal/log"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/util/analyzer"
+)
+
+func TestExtractResources(t *testing.T) {
+	page := `<html><head>
+		<script src="/app.js"></script>
+		<script>inline()</script>
+		<link rel="stylesheet" href="https://cdn.example.com/site.css">
+		<link rel="shortcut icon" href="/favicon.ico">
+		<link rel="preload" as="font" href="https://fonts.gstatic.com/font.woff2" crossorigin>
+		<link rel="preload" as="fetch" href="/data.json">
+		<link rel="canonical" href="/page">
+	</head><body>
+		<img src="/logo.png" srcset="/logo.png 1x, /logo@2x.png 2x">
+		<img src="data:image/png;base64,AAAA">
+		<picture><source srcset="/hero.webp"><img src="/hero.jpg"></picture>
+		<video src="/clip.mp4" poster="/clip.jpg"><source src="/clip.webm"></video>
+		<iframe src="https://www.youtube.com/embed/x"></iframe>
+		<object data="/doc.pdf"></object>
+		<script src="/app.js#again"></script>
+	</body></html>`
+	root, _ := html.Parse(strings.NewReader(page))
+	baseURL, _ := url.Parse("https://www.example.com/page")
+
+	resources := extractResources(root, baseURL)
+
+	expected := []struct {
+		resourceType string
+		url          string
+		thirdParty   bool
+	}{
+		{analyzer.ResourceScript, "https://www.example.com/app.js", false},
+		{analyzer.ResourceStylesheet, "https://cdn.example.com/site.css", false},
+		{analyzer.ResourceIcon, "https://www.example.com/favicon.ico", false},
+		{analyzer.ResourceFont, "https://fonts.gstatic.com/font.woff2", true},
+		{analyzer.ResourcePreload, "https://www.example.com/data.json", false},
+		{analyzer.ResourceImage, "https://www.example.com/logo.png", false},
+		{analyzer.ResourceImage, "https://www.example.com/logo@2x.png", false},
+		{analyzer.ResourceImage, "https://www.example.com/hero.webp", false},
+		{analyzer.ResourceImage, "https://www.example.com/hero.jpg", false},
+		{analyzer.ResourceMedia, "https://www.example.com/clip.mp4", false},
+		{analyzer.ResourceImage, "https://www.example.com/clip.jpg", false},
+		{analyzer.ResourceMedia, "https://www.example.com/clip.webm", false},
+		{analyzer.ResourceIframe, "https://www.youtube.com/embed/x", true},
+		{analyzer.ResourceObject, "https://www.example.com/doc.pdf", false},
+	}
+	if len(resources) != len(expected) {
+		t.Fatalf("extractResources() returned %d resources, want %d: %+v", len(resources), len(expected), resources)
+	}
+	for i, want := range expected {
+		got := resources[i]
+		if got.Type != want.resourceType || got.ResolvedURL != want.url || got.IsThirdParty != want.thirdParty {
+			t.Errorf("resource %d = %+v, want %s %s third party %v", i, got, want.resourceType, want.url, want.thirdParty)
+		}
+	}
+}
+
+func TestParseSrcset(t *testing.T) {
+	tests := []struct {
+		srcset   string
+		expected []string
+	}{
+		{"", nil},
+		{"a.png", []string{"a.png"}},
+		{"a.png 1x, b.png 2x", []string{"a.png", "b.png"}},
+		{"a.png 480w,b.png 800w", []string{"a.png", "b.png"}},
+		{"a.png, b.png 2x", []string{"a.png", "b.png"}},
+		{"  /img/a,b.png 100w , c.png", []string{"/img/a,b.png", "c.png"}},
+	}
+
+	for _, tt := range tests {
+		if got := parseSrcset(tt.srcset); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("parseSrcset(%q) = %q, want %q", tt.srcset, got, tt.expected)
+		}
+	}
+}
+
+func TestAnalyzePageResources(t *testing.T) {
+	log.Logger = zap.NewNop()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><head><script src="/app.js"></script><link rel="stylesheet" href="/site.css"></head><body><img src="/missing.png"><img src="/app.js"></body></html>`)
+		case "/app.js":
+			w.Header().Set("Content-Length", "1200")
+		case "/site.css":
+			w.Header().Set("Content-Length", "300")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	page, err := AnalyzePage(context.Background(), server.URL, model.AnalyzeOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzePage() unexpected error: %v", err)
+	}
+	if page.Resources.Checked || page.Resources.ResourceBytes != 0 || len(page.Resources.Items) != 4 {
+		t.Errorf("unchecked resources = %+v, want 4 items without sizes", page.Resources)
+	}
+
+	page, err = AnalyzePage(context.Background(), server.URL, model.AnalyzeOptions{CheckResources: true})
+	if err != nil {
+		t.Fatalf("AnalyzePage() unexpected error: %v", err)
+	}
+	resources := page.Resources
+	if !resources.Checked || resources.FirstPartyCount != 4 || resources.CountsByType[analyzer.ResourceImage] != 2 {
+		t.Errorf("resources = %+v, want 4 checked first party resources", resources)
+	}
+	if resources.ResourceBytes != 1500 || resources.UnknownSizeCount != 1 {
+		t.Errorf("resource bytes = %d, unknown = %d, want 1500 and 1", resources.ResourceBytes, resources.UnknownSizeCount)
+	}
+	if resources.TotalBytes != resources.HTMLBytes+1500 {
+		t.Errorf("total bytes = %d, want html %d + 1500", resources.TotalBytes, resources.HTMLBytes)
+	}
+	if missing := resources.Items[2]; missing.IsAccessible || missing.StatusCode != http.StatusNotFound {
+		t.Errorf("missing image = %+v, want 404", missing)
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/robots"
+	"webanalyzer/internal/util/analyzer"
+)
+
+var ErrRobotsDisallowed = errors.New("target page is disallowed by robots.txt")
+
+func newRobotsCache(transport http.RoundTripper) *robots.Cache {
+	return robots.NewCache(&http.Client{Timeout: analyzer.LinkCheckTimeout, Transport: transport}, analyzer.UserAgent, analyzer.RobotsCacheTTL, analyzer.RobotsCacheSize)
+}
+
+// checks the robots.txt rules of the page at u for our user agent
+func checkRobots(ctx context.Context, u *url.URL, opts model.AnalyzeOptions) *model.RobotsStatus {
+	rules := currentRobotsRules().Get(ctx, u)
+	return &model.RobotsStatus{
+		RobotsURL:         robots.RobotsURL(u),
+		UserAgent:         analyzer.UserAgent,
+		Allowed:           rules.Allowed(analyzer.UserAgent, u),
+		Ignored:           opts.IgnoreRobots,
+		CrawlDelaySeconds: rules.CrawlDelay(analyzer.UserAgent).Seconds(),
+	}
+}
+
+// reports whether robots.txt allows checking the link, always true when robots are ignored
+func linkAllowedByRobots(ctx context.Context, u *url.URL, opts model.AnalyzeOptions) bool {
+	if opts.IgnoreRobots {
+		return true
+	}
+	return currentRobotsRules().Get(ctx, u).Allowed(analyzer.UserAgent, u)
+}
+
+// waits for the per host politeness delay, stretched to the Crawl-delay of the host unless robots are ignored
+func waitForHost(ctx context.Context, hosts *hostLimiter, u *url.URL, opts model.AnalyzeOptions) error {
+	applyCrawlDelay(ctx, hosts, u, opts)
+	return hosts.Wait(ctx, u.Host)
+}
+
+// linkCheck is what the link, social image and resource checks of one analysis share
+type linkCheck struct {
+	// hosts spaces out the checks of links to the analyzed host
+	hosts *hostLimiter
+	// scope is the URL the caller asked for, only its host receives the custom request options
+	scope *url.URL
+}
+
+// spaces the link, resource and social image checks of one analysis to the analyzed host by its Crawl-delay,
+// the page request counts as the first request to the host when the page was fetched
+func newLinkCheckLimiter(ctx context.Context, baseURL *url.URL, opts model.AnalyzeOptions, fetched bool) *hostLimiter {
+	hosts := newHostLimiter(0)
+	if baseURL.Host == "" {
+		return hosts
+	}
+	applyCrawlDelay(ctx, hosts, baseURL, opts)
+	if fetched {
+		hosts.limiter(baseURL.Host).Allow()
+	}
+	return hosts
+}
+
+// stretches the interval of requests to the host of u to its Crawl-delay unless robots are ignored
+func applyCrawlDelay(ctx context.Context, hosts *hostLimiter, u *url.URL, opts model.AnalyzeOptions) {
+	if opts.IgnoreRobots {
+		return
+	}
+	delay := currentRobotsRules().Get(ctx, u).CrawlDelay(analyzer.UserAgent)
+	if delay > analyzer.MaxCrawlDelay {
+		delay = analyzer.MaxCrawlDelay
+	}
+	hosts.SetMinInterval(u.Host, delay)
+}
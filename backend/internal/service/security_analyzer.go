@@ -0,0 +1,472 @@
+package service
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/util/analyzer"
+)
+
+// resource types browsers load over http:// from an HTTPS page, with a warning, instead of blocking them
+var passiveResourceTypes = map[string]bool{
+	analyzer.ResourceImage: true,
+	analyzer.ResourceMedia: true,
+	analyzer.ResourceIcon:  true,
+}
+
+// lists the http:// subresources and form actions of an HTTPS page, nothing is reported for http:// pages
+func detectMixedContent(root *html.Node, resources []model.Resource, baseURL *url.URL) []model.MixedContentItem {
+	items := []model.MixedContentItem{}
+	if baseURL.Scheme != "https" {
+		return items
+	}
+
+	for _, resource := range resources {
+		if !strings.HasPrefix(resource.ResolvedURL, "http://") {
+			continue
+		}
+		category := analyzer.MixedContentActive
+		if passiveResourceTypes[resource.Type] {
+			category = analyzer.MixedContentPassive
+		}
+		items = append(items, model.MixedContentItem{
+			URL:       resource.ResolvedURL,
+			Tag:       resource.Tag,
+			Attribute: resource.Attribute,
+			Type:      resource.Type,
+			Category:  category,
+		})
+	}
+
+	var visit func(*html.Node)
+	visit = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Namespace == "" {
+			attribute := ""
+			switch node.Data {
+			case "form":
+				attribute = "action"
+			case "button", "input":
+				attribute = "formaction"
+			}
+			if attribute != "" {
+				if action := strings.TrimSpace(analyzer.GetAttr(node, attribute)); action != "" {
+					if resolved := resolveURL(baseURL, action); strings.HasPrefix(resolved, "http://") {
+						items = append(items, model.MixedContentItem{
+							URL:       resolved,
+							Tag:       node.Data,
+							Attribute: attribute,
+							Type:      "form_action",
+							Category:  analyzer.MixedContentForm,
+						})
+					}
+				}
+			}
+		}
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(root)
+
+	return items
+}
+
+// points of each header check in the security header score, they add up to 100
+const (
+	hstsPoints              = 25
+	cspPoints               = 25
+	frameOptionsPoints      = 15
+	contentTypeOptionPoints = 10
+	referrerPolicyPoints    = 10
+	permissionsPolicyPoints = 5
+	cookiePoints            = 10
+)
+
+// referrer policies that send the full URL to other origins
+var leakyReferrerPolicies = map[string]bool{
+	"unsafe-url":                 true,
+	"no-referrer-when-downgrade": true,
+}
+
+var validReferrerPolicies = map[string]bool{
+	"no-referrer":                     true,
+	"no-referrer-when-downgrade":      true,
+	"origin":                          true,
+	"origin-when-cross-origin":        true,
+	"same-origin":                     true,
+	"strict-origin":                   true,
+	"strict-origin-when-cross-origin": true,
+	"unsafe-url":                      true,
+}
+
+// grades the security headers and cookies of the page response
+func auditSecurityHeaders(header http.Header, pageURL *url.URL) *model.SecurityHeaders {
+	isHTTPS := pageURL.Scheme == "https"
+	audit := &model.SecurityHeaders{
+		StrictTransportSecurity: checkHSTS(header, isHTTPS),
+		ContentSecurityPolicy:   checkCSP(header),
+		XContentTypeOptions:     checkContentTypeOptions(header),
+		ReferrerPolicy:          checkReferrerPolicy(header),
+		PermissionsPolicy:       checkPermissionsPolicy(header),
+		Cookies:                 checkCookies(header, isHTTPS),
+	}
+	audit.XFrameOptions = checkFrameOptions(header, audit.ContentSecurityPolicy)
+
+	score := 0
+	if audit.StrictTransportSecurity.Passed {
+		score += hstsPoints
+	} else if audit.StrictTransportSecurity.MaxAgeSeconds > 0 && isHTTPS {
+		score += hstsPoints / 2
+	}
+	if audit.ContentSecurityPolicy.Passed {
+		score += cspPoints
+	} else if audit.ContentSecurityPolicy.Present && !audit.ContentSecurityPolicy.ReportOnly {
+		score += cspPoints / 2
+	}
+	if audit.XFrameOptions.Passed {
+		score += frameOptionsPoints
+	}
+	if audit.XContentTypeOptions.Passed {
+		score += contentTypeOptionPoints
+	}
+	if audit.ReferrerPolicy.Passed {
+		score += referrerPolicyPoints
+	}
+	if audit.PermissionsPolicy.Passed {
+		score += permissionsPolicyPoints
+	}
+	score += cookieScore(audit.Cookies)
+
+	audit.Score = score
+	audit.Grade = securityGrade(score)
+	return audit
+}
+
+func newHeaderCheck(header http.Header, name string) model.HeaderCheck {
+	check := model.HeaderCheck{Header: name, Warnings: []string{}}
+	if values := header.Values(name); len(values) > 0 {
+		check.Present = true
+		check.Value = strings.TrimSpace(strings.Join(values, ", "))
+	}
+	return check
+}
+
+// checks Strict-Transport-Security, which browsers only honor on HTTPS responses
+func checkHSTS(header http.Header, isHTTPS bool) model.HSTSCheck {
+	check := model.HSTSCheck{HeaderCheck: newHeaderCheck(header, "Strict-Transport-Security")}
+	if !isHTTPS {
+		check.Warnings = append(check.Warnings, "page is served over plain HTTP, HSTS cannot protect it")
+		return check
+	}
+	if !check.Present {
+		check.Warnings = append(check.Warnings, "header is missing, the first visit can be downgraded to HTTP")
+		return check
+	}
+
+	maxAgeFound := false
+	// only the first header is used when several are sent
+	for _, directive := range strings.Split(header.Get("Strict-Transport-Security"), ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "max-age":
+			maxAge, err := strconv.ParseInt(strings.Trim(strings.TrimSpace(value), `"`), 10, 64)
+			if err == nil && maxAge >= 0 {
+				check.MaxAgeSeconds = maxAge
+				maxAgeFound = true
+			}
+		case "includesubdomains":
+			check.IncludeSubDomains = true
+		case "preload":
+			check.Preload = true
+		}
+	}
+
+	switch {
+	case !maxAgeFound:
+		check.Warnings = append(check.Warnings, "max-age is missing or invalid, the header is ignored")
+	case check.MaxAgeSeconds == 0:
+		check.Warnings = append(check.Warnings, "max-age=0 removes the HSTS policy")
+	case check.MaxAgeSeconds < analyzer.MinHSTSMaxAgeSeconds:
+		check.Warnings = append(check.Warnings, fmt.Sprintf("max-age is shorter than %d seconds", analyzer.MinHSTSMaxAgeSeconds))
+	default:
+		check.Passed = true
+	}
+	if check.Preload && (!check.IncludeSubDomains || check.MaxAgeSeconds < 31536000) {
+		check.Warnings = append(check.Warnings, "preload requires includeSubDomains and a max-age of at least one year")
+	}
+	return check
+}
+
+// checks Content-Security-Policy, falling back to the report only header which is not enforced
+func checkCSP(header http.Header) model.CSPCheck {
+	check := model.CSPCheck{HeaderCheck: newHeaderCheck(header, "Content-Security-Policy")}
+	if !check.Present {
+		reportOnly := newHeaderCheck(header, "Content-Security-Policy-Report-Only")
+		if !reportOnly.Present {
+			check.Warnings = append(check.Warnings, "header is missing, scripts from any origin can run")
+			return check
+		}
+		check.HeaderCheck = reportOnly
+		check.ReportOnly = true
+	}
+
+	// a resource has to pass every policy, so one policy that restricts a directive is enough
+	policies := parseCSP(check.Value)
+	check.Directives = mergeCSP(policies)
+	if check.ReportOnly {
+		check.Warnings = append(check.Warnings, "policy is report only and not enforced")
+	}
+
+	var scriptWarnings []string
+	scriptsSet, scriptsRestricted := false, false
+	styleInline, stylesRestricted := false, false
+	for _, directives := range policies {
+		warnings, set := auditScriptSources(directives)
+		scriptsSet = scriptsSet || set
+		scriptsRestricted = scriptsRestricted || (set && len(warnings) == 0)
+		for _, warning := range warnings {
+			if !containsToken(scriptWarnings, warning) {
+				scriptWarnings = append(scriptWarnings, warning)
+			}
+		}
+
+		styleSources, ok := directives["style-src"]
+		if ok && containsToken(styleSources, "'unsafe-inline'") {
+			styleInline = true
+		}
+		if !ok {
+			styleSources, ok = directives["default-src"]
+		}
+		stylesRestricted = stylesRestricted || (ok && !containsToken(styleSources, "'unsafe-inline'"))
+	}
+	if !scriptsSet {
+		check.Warnings = append(check.Warnings, "neither script-src nor default-src is set, scripts are not restricted")
+	}
+	if !scriptsRestricted {
+		check.Warnings = append(check.Warnings, scriptWarnings...)
+	}
+	if styleInline && !stylesRestricted {
+		check.Warnings = append(check.Warnings, "style-src allows 'unsafe-inline'")
+	}
+
+	check.Passed = scriptsRestricted && !check.ReportOnly
+	return check
+}
+
+// the weaknesses of the script sources of one policy, falling back to default-src.
+// set is false when the policy restricts no script source at all
+func auditScriptSources(directives map[string][]string) (warnings []string, set bool) {
+	sources, set := directives["script-src"]
+	if !set {
+		sources, set = directives["default-src"]
+	}
+	for _, source := range sources {
+		switch strings.ToLower(source) {
+		case "'unsafe-inline'":
+			if !allowsInlineByNonceOrHash(sources) {
+				warnings = append(warnings, "script-src allows 'unsafe-inline'")
+			}
+		case "'unsafe-eval'":
+			warnings = append(warnings, "script-src allows 'unsafe-eval'")
+		case "*", "http:", "https:", "data:":
+			warnings = append(warnings, fmt.Sprintf("script-src allows scripts from %s", source))
+		}
+	}
+	return warnings, set
+}
+
+// splits a header value into its policies, several policies are joined with commas.
+// each policy maps lowercase directive names to their source lists, the first occurrence of a directive wins
+func parseCSP(value string) []map[string][]string {
+	var policies []map[string][]string
+	for _, policy := range strings.Split(value, ",") {
+		directives := make(map[string][]string)
+		for _, part := range strings.Split(policy, ";") {
+			fields := strings.Fields(part)
+			if len(fields) == 0 {
+				continue
+			}
+			name := strings.ToLower(fields[0])
+			if _, exists := directives[name]; exists {
+				continue
+			}
+			directives[name] = fields[1:]
+		}
+		if len(directives) > 0 {
+			policies = append(policies, directives)
+		}
+	}
+	return policies
+}
+
+// the directives of several policies, a directive is taken from the first policy that sets it
+func mergeCSP(policies []map[string][]string) map[string][]string {
+	merged := make(map[string][]string)
+	for _, directives := range policies {
+		for name, sources := range directives {
+			if _, exists := merged[name]; !exists {
+				merged[name] = sources
+			}
+		}
+	}
+	return merged
+}
+
+// browsers ignore 'unsafe-inline' when the source list has a nonce or a hash
+func allowsInlineByNonceOrHash(sources []string) bool {
+	for _, source := range sources {
+		source = strings.ToLower(source)
+		if strings.HasPrefix(source, "'nonce-") || strings.HasPrefix(source, "'sha256-") ||
+			strings.HasPrefix(source, "'sha384-") || strings.HasPrefix(source, "'sha512-") {
+			return true
+		}
+	}
+	return false
+}
+
+// checks X-Frame-Options, a CSP frame-ancestors directive replaces it
+func checkFrameOptions(header http.Header, csp model.CSPCheck) model.HeaderCheck {
+	check := newHeaderCheck(header, "X-Frame-Options")
+	_, hasFrameAncestors := csp.Directives["frame-ancestors"]
+	hasFrameAncestors = hasFrameAncestors && !csp.ReportOnly
+
+	switch value := strings.ToUpper(check.Value); {
+	case !check.Present && hasFrameAncestors:
+		check.Passed = true
+	case !check.Present:
+		check.Warnings = append(check.Warnings, "header is missing and CSP has no frame-ancestors, the page can be framed for clickjacking")
+	case value == "DENY", value == "SAMEORIGIN":
+		check.Passed = true
+	case strings.HasPrefix(value, "ALLOW-FROM"):
+		check.Warnings = append(check.Warnings, "ALLOW-FROM is obsolete and ignored by browsers, use CSP frame-ancestors")
+		check.Passed = hasFrameAncestors
+	default:
+		check.Warnings = append(check.Warnings, fmt.Sprintf("invalid value %q", check.Value))
+		check.Passed = hasFrameAncestors
+	}
+	return check
+}
+
+func checkContentTypeOptions(header http.Header) model.HeaderCheck {
+	check := newHeaderCheck(header, "X-Content-Type-Options")
+	switch {
+	case !check.Present:
+		check.Warnings = append(check.Warnings, "header is missing, browsers may sniff the content type")
+	case strings.EqualFold(check.Value, "nosniff"):
+		check.Passed = true
+	default:
+		check.Warnings = append(check.Warnings, fmt.Sprintf("invalid value %q, only nosniff is supported", check.Value))
+	}
+	return check
+}
+
+// checks Referrer-Policy, browsers use the last policy of the list they support
+func checkReferrerPolicy(header http.Header) model.HeaderCheck {
+	check := newHeaderCheck(header, "Referrer-Policy")
+	if !check.Present {
+		check.Warnings = append(check.Warnings, "header is missing, the browser default policy is used")
+		return check
+	}
+
+	policy := ""
+	for _, value := range strings.Split(check.Value, ",") {
+		if value = strings.ToLower(strings.TrimSpace(value)); validReferrerPolicies[value] {
+			policy = value
+		}
+	}
+	switch {
+	case policy == "":
+		check.Warnings = append(check.Warnings, fmt.Sprintf("invalid value %q", check.Value))
+	case leakyReferrerPolicies[policy]:
+		check.Warnings = append(check.Warnings, fmt.Sprintf("%s sends the full URL to other origins", policy))
+	default:
+		check.Passed = true
+	}
+	return check
+}
+
+func checkPermissionsPolicy(header http.Header) model.HeaderCheck {
+	check := newHeaderCheck(header, "Permissions-Policy")
+	switch {
+	case check.Present && check.Value != "":
+		check.Passed = true
+	case header.Get("Feature-Policy") != "":
+		check.Warnings = append(check.Warnings, "only the deprecated Feature-Policy header is set")
+	default:
+		check.Warnings = append(check.Warnings, "header is missing, browser features are not restricted")
+	}
+	return check
+}
+
+// checks the Secure, HttpOnly and SameSite attributes of the cookies set by the page
+func checkCookies(header http.Header, isHTTPS bool) []model.CookieCheck {
+	checks := []model.CookieCheck{}
+	for _, line := range header.Values("Set-Cookie") {
+		cookie, err := http.ParseSetCookie(line)
+		if err != nil {
+			continue
+		}
+		check := model.CookieCheck{
+			Name:     cookie.Name,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+			Warnings: []string{},
+		}
+		switch cookie.SameSite {
+		case http.SameSiteStrictMode:
+			check.SameSite = "Strict"
+		case http.SameSiteLaxMode:
+			check.SameSite = "Lax"
+		case http.SameSiteNoneMode:
+			check.SameSite = "None"
+		}
+
+		if !cookie.Secure && isHTTPS {
+			check.Warnings = append(check.Warnings, "cookie is not Secure and can be sent over plain HTTP")
+		}
+		if !cookie.HttpOnly {
+			check.Warnings = append(check.Warnings, "cookie is not HttpOnly and can be read by scripts")
+		}
+		switch {
+		case check.SameSite == "":
+			check.Warnings = append(check.Warnings, "SameSite is not set")
+		case check.SameSite == "None" && !cookie.Secure:
+			check.Warnings = append(check.Warnings, "SameSite=None requires Secure, browsers reject the cookie")
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// cookie points shared by the cookies without warnings, full points when no cookie is set
+func cookieScore(cookies []model.CookieCheck) int {
+	if len(cookies) == 0 {
+		return cookiePoints
+	}
+	good := 0
+	for _, cookie := range cookies {
+		if len(cookie.Warnings) == 0 {
+			good++
+		}
+	}
+	return cookiePoints * good / len(cookies)
+}
+
+func securityGrade(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= 60:
+		return "D"
+	default:
+		return "F"
+	}
+}
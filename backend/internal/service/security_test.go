@@ -0,0 +1,212 @@
+package service
+
+import (
+	"golang.org/x/net/html"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/util/analyzer"
+)
+
+func TestDetectMixedContent(t *testing.T) {
+	page := `<html><head>
+		<script src="http://cdn.example.com/app.js"></script>
+		<link rel="stylesheet" href="HTTP://cdn.example.com/site.css">
+		<script src="https://cdn.example.com/safe.js"></script>
+	</head><body>
+		<img src="http://images.example.com/a.png">
+		<video src="//media.example.com/clip.mp4"></video>
+		<iframe src="http://widgets.example.com/frame"></iframe>
+		<form action="http://example.com/login"><button formaction="http://example.com/alt">Go</button></form>
+		<form action="/search"></form>
+		<a href="http://example.com/plain">plain links are not mixed content</a>
+	</body></html>`
+	root, _ := html.Parse(strings.NewReader(page))
+
+	tests := []struct {
+		name     string
+		pageURL  string
+		expected []model.MixedContentItem
+	}{
+		{
+			name:    "HTTPS page",
+			pageURL: "https://example.com/",
+			expected: []model.MixedContentItem{
+				{URL: "http://cdn.example.com/app.js", Tag: "script", Attribute: "src", Type: analyzer.ResourceScript, Category: analyzer.MixedContentActive},
+				{URL: "http://cdn.example.com/site.css", Tag: "link", Attribute: "href", Type: analyzer.ResourceStylesheet, Category: analyzer.MixedContentActive},
+				{URL: "http://images.example.com/a.png", Tag: "img", Attribute: "src", Type: analyzer.ResourceImage, Category: analyzer.MixedContentPassive},
+				{URL: "http://widgets.example.com/frame", Tag: "iframe", Attribute: "src", Type: analyzer.ResourceIframe, Category: analyzer.MixedContentActive},
+				{URL: "http://example.com/login", Tag: "form", Attribute: "action", Type: "form_action", Category: analyzer.MixedContentForm},
+				{URL: "http://example.com/alt", Tag: "button", Attribute: "formaction", Type: "form_action", Category: analyzer.MixedContentForm},
+			},
+		},
+		{
+			name:     "HTTP page",
+			pageURL:  "http://example.com/",
+			expected: []model.MixedContentItem{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseURL, _ := url.Parse(tt.pageURL)
+			items := detectMixedContent(root, extractResources(root, baseURL), baseURL)
+			if len(items) != len(tt.expected) {
+				t.Fatalf("detectMixedContent() = %+v, want %d items", items, len(tt.expected))
+			}
+			for i, want := range tt.expected {
+				if items[i] != want {
+					t.Errorf("item %d = %+v, want %+v", i, items[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestAuditSecurityHeaders(t *testing.T) {
+	httpsURL, _ := url.Parse("https://example.com/")
+	httpURL, _ := url.Parse("http://example.com/")
+
+	strong := http.Header{}
+	strong.Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains; preload")
+	strong.Set("Content-Security-Policy", "default-src 'self'; script-src 'self' 'nonce-abc' 'unsafe-inline'; frame-ancestors 'none'")
+	strong.Set("X-Content-Type-Options", "nosniff")
+	strong.Set("Referrer-Policy", "no-referrer, strict-origin-when-cross-origin")
+	strong.Set("Permissions-Policy", "geolocation=()")
+	strong.Add("Set-Cookie", "session=secret; Path=/; Secure; HttpOnly; SameSite=Lax")
+
+	weak := http.Header{}
+	weak.Set("Strict-Transport-Security", "max-age=3600")
+	weak.Set("Content-Security-Policy", "script-src 'self' 'unsafe-inline' 'unsafe-eval' https:; style-src 'unsafe-inline'")
+	weak.Set("X-Frame-Options", "ALLOW-FROM https://example.org")
+	weak.Set("Referrer-Policy", "unsafe-url")
+	weak.Set("Feature-Policy", "camera 'none'")
+	weak.Add("Set-Cookie", "tracking=1; SameSite=None")
+	weak.Add("Set-Cookie", "prefs=dark; Secure; HttpOnly; SameSite=Strict")
+
+	tests := []struct {
+		name    string
+		header  http.Header
+		pageURL *url.URL
+		score   int
+		grade   string
+		check   func(t *testing.T, audit *model.SecurityHeaders)
+	}{
+		{
+			name:    "strong headers",
+			header:  strong,
+			pageURL: httpsURL,
+			score:   100,
+			grade:   "A",
+			check: func(t *testing.T, audit *model.SecurityHeaders) {
+				hsts := audit.StrictTransportSecurity
+				if hsts.MaxAgeSeconds != 31536000 || !hsts.IncludeSubDomains || !hsts.Preload || len(hsts.Warnings) != 0 {
+					t.Errorf("HSTS = %+v", hsts)
+				}
+				if !audit.XFrameOptions.Passed || audit.XFrameOptions.Present {
+					t.Errorf("X-Frame-Options = %+v, want passed by frame-ancestors", audit.XFrameOptions)
+				}
+				if sources := audit.ContentSecurityPolicy.Directives["script-src"]; len(sources) != 3 {
+					t.Errorf("script-src = %v", sources)
+				}
+				if cookie := audit.Cookies[0]; cookie.Name != "session" || cookie.SameSite != "Lax" || len(cookie.Warnings) != 0 {
+					t.Errorf("cookie = %+v", cookie)
+				}
+			},
+		},
+		{
+			name:    "weak headers",
+			header:  weak,
+			pageURL: httpsURL,
+			// half of HSTS and CSP, half of the cookie points
+			score: 12 + 12 + 5,
+			grade: "F",
+			check: func(t *testing.T, audit *model.SecurityHeaders) {
+				if warnings := audit.ContentSecurityPolicy.Warnings; len(warnings) != 4 {
+					t.Errorf("CSP warnings = %v, want unsafe-inline, unsafe-eval, https: and style-src", warnings)
+				}
+				if audit.XFrameOptions.Passed || len(audit.XFrameOptions.Warnings) != 1 {
+					t.Errorf("X-Frame-Options = %+v, want failed ALLOW-FROM", audit.XFrameOptions)
+				}
+				if audit.ReferrerPolicy.Passed || audit.PermissionsPolicy.Passed {
+					t.Errorf("referrer policy = %+v, permissions policy = %+v, want failed", audit.ReferrerPolicy, audit.PermissionsPolicy)
+				}
+				if warnings := audit.Cookies[0].Warnings; len(warnings) != 3 {
+					t.Errorf("cookie warnings = %v, want Secure, HttpOnly and SameSite=None", warnings)
+				}
+			},
+		},
+		{
+			name:    "no headers over HTTP",
+			header:  http.Header{},
+			pageURL: httpURL,
+			score:   cookiePoints,
+			grade:   "F",
+			check: func(t *testing.T, audit *model.SecurityHeaders) {
+				if audit.StrictTransportSecurity.Passed || len(audit.StrictTransportSecurity.Warnings) != 1 {
+					t.Errorf("HSTS = %+v, want not applicable over HTTP", audit.StrictTransportSecurity)
+				}
+				if len(audit.Cookies) != 0 {
+					t.Errorf("cookies = %+v, want none", audit.Cookies)
+				}
+			},
+		},
+		{
+			name: "report only policy",
+			header: http.Header{
+				"Content-Security-Policy-Report-Only": {"default-src 'self'"},
+				"X-Frame-Options":                     {"sameorigin"},
+				"X-Content-Type-Options":              {"nosniff"},
+			},
+			pageURL: httpURL,
+			score:   frameOptionsPoints + contentTypeOptionPoints + cookiePoints,
+			grade:   "F",
+			check: func(t *testing.T, audit *model.SecurityHeaders) {
+				if csp := audit.ContentSecurityPolicy; !csp.ReportOnly || csp.Passed {
+					t.Errorf("CSP = %+v, want report only and not passed", csp)
+				}
+			},
+		},
+		{
+			name: "one of several policies restricts scripts",
+			header: http.Header{
+				"Content-Security-Policy": {"script-src 'unsafe-inline' https:; style-src 'unsafe-inline'", "default-src 'self'"},
+			},
+			pageURL: httpURL,
+			score:   cspPoints + cookiePoints,
+			grade:   "F",
+			check: func(t *testing.T, audit *model.SecurityHeaders) {
+				if csp := audit.ContentSecurityPolicy; !csp.Passed || len(csp.Warnings) != 0 {
+					t.Errorf("CSP = %+v, want passed without warnings", csp)
+				}
+			},
+		},
+		{
+			name: "no policy restricts scripts",
+			header: http.Header{
+				"Content-Security-Policy": {"script-src https:", "default-src 'self' 'unsafe-eval'"},
+			},
+			pageURL: httpURL,
+			// half of CSP
+			score: 12 + cookiePoints,
+			grade: "F",
+			check: func(t *testing.T, audit *model.SecurityHeaders) {
+				if csp := audit.ContentSecurityPolicy; csp.Passed || len(csp.Warnings) != 2 {
+					t.Errorf("CSP = %+v, want the https: and unsafe-eval warnings", csp)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := auditSecurityHeaders(tt.header, tt.pageURL)
+			if audit.Score != tt.score || audit.Grade != tt.grade {
+				t.Errorf("auditSecurityHeaders() score = %d %s, want %d %s", audit.Score, audit.Grade, tt.score, tt.grade)
+			}
+			tt.check(t, audit)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/util/analyzer"
+)
+
+// extracts the title, meta description, robots directives, canonical URL and hreflang alternates of the page
+// and reports common SEO problems with them
+func extractSEO(root *html.Node, baseURL *url.URL) *model.SEO {
+	seo := &model.SEO{
+		MetaRobots: []string{},
+		Googlebot:  []string{},
+		Hreflang:   []model.HreflangAlternate{},
+		Warnings:   []string{},
+	}
+
+	var titles, descriptions, canonicals []string
+	seenHreflang := make(map[string]bool)
+
+	var visitNode func(*html.Node)
+	visitNode = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Namespace == "" {
+			switch node.Data {
+			case "title":
+				titles = append(titles, strings.TrimSpace(analyzer.ExtractInnerText(node)))
+			case "meta":
+				name := strings.ToLower(strings.TrimSpace(analyzer.GetAttr(node, "name")))
+				content := strings.TrimSpace(analyzer.GetAttr(node, "content"))
+				switch name {
+				case "description":
+					descriptions = append(descriptions, content)
+				case "robots":
+					seo.MetaRobots = append(seo.MetaRobots, parseRobotsDirectives(content)...)
+				case "googlebot":
+					seo.Googlebot = append(seo.Googlebot, parseRobotsDirectives(content)...)
+				}
+			case "link":
+				rel := strings.Fields(strings.ToLower(analyzer.GetAttr(node, "rel")))
+				href := strings.TrimSpace(analyzer.GetAttr(node, "href"))
+				switch {
+				case containsToken(rel, "canonical"):
+					canonicals = append(canonicals, href)
+				case containsToken(rel, "alternate") && analyzer.HasAttr(node, "hreflang"):
+					lang := strings.TrimSpace(analyzer.GetAttr(node, "hreflang"))
+					alternate := model.HreflangAlternate{Lang: lang, URL: resolveURL(baseURL, href)}
+					if seenHreflang[strings.ToLower(lang)] {
+						seo.Warnings = append(seo.Warnings, fmt.Sprintf("duplicate hreflang %q", lang))
+					}
+					seenHreflang[strings.ToLower(lang)] = true
+					seo.Hreflang = append(seo.Hreflang, alternate)
+				}
+			}
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			visitNode(child)
+		}
+	}
+	visitNode(root)
+
+	seo.TitleCount = len(titles)
+	switch {
+	case len(titles) == 0:
+		seo.Warnings = append(seo.Warnings, "missing title")
+	case len(titles) > 1:
+		seo.Warnings = append(seo.Warnings, fmt.Sprintf("multiple title elements (%d)", len(titles)))
+	}
+	if len(titles) > 0 {
+		seo.Title = titles[0]
+		seo.TitleLength = utf8.RuneCountInString(seo.Title)
+		switch {
+		case seo.Title == "":
+			seo.Warnings = append(seo.Warnings, "empty title")
+		case seo.TitleLength < analyzer.MinTitleLength:
+			seo.Warnings = append(seo.Warnings, fmt.Sprintf("title is shorter than %d characters", analyzer.MinTitleLength))
+		case seo.TitleLength > analyzer.MaxTitleLength:
+			seo.Warnings = append(seo.Warnings, fmt.Sprintf("title is longer than %d characters", analyzer.MaxTitleLength))
+		}
+	}
+
+	switch {
+	case len(descriptions) == 0:
+		seo.Warnings = append(seo.Warnings, "missing meta description")
+	case len(descriptions) > 1:
+		seo.Warnings = append(seo.Warnings, fmt.Sprintf("multiple meta descriptions (%d)", len(descriptions)))
+	}
+	if len(descriptions) > 0 {
+		seo.MetaDescription = descriptions[0]
+		seo.MetaDescriptionLength = utf8.RuneCountInString(seo.MetaDescription)
+		switch {
+		case seo.MetaDescription == "":
+			seo.Warnings = append(seo.Warnings, "empty meta description")
+		case seo.MetaDescriptionLength < analyzer.MinMetaDescriptionLength:
+			seo.Warnings = append(seo.Warnings, fmt.Sprintf("meta description is shorter than %d characters", analyzer.MinMetaDescriptionLength))
+		case seo.MetaDescriptionLength > analyzer.MaxMetaDescriptionLength:
+			seo.Warnings = append(seo.Warnings, fmt.Sprintf("meta description is longer than %d characters", analyzer.MaxMetaDescriptionLength))
+		}
+	}
+
+	if containsToken(seo.MetaRobots, "noindex") || containsToken(seo.Googlebot, "noindex") {
+		seo.Warnings = append(seo.Warnings, "page is marked noindex")
+	}
+
+	switch {
+	case len(canonicals) == 0:
+		seo.Warnings = append(seo.Warnings, "missing canonical link")
+	case len(canonicals) > 1:
+		seo.Warnings = append(seo.Warnings, fmt.Sprintf("multiple canonical links (%d)", len(canonicals)))
+	}
+	if len(canonicals) > 0 {
+		seo.Canonical = resolveURL(baseURL, canonicals[0])
+		seo.IsSelfCanonical = seo.Canonical != "" && normalizeLinkURL(seo.Canonical) == normalizeCrawlURL(baseURL)
+		if !seo.IsSelfCanonical {
+			seo.Warnings = append(seo.Warnings, "canonical URL points to a different page")
+		}
+	}
+
+	return seo
+}
+
+// splits a robots meta content such as "noindex, nofollow" into lowercase directives
+func parseRobotsDirectives(content string) []string {
+	var directives []string
+	for _, part := range strings.Split(content, ",") {
+		if directive := strings.ToLower(strings.TrimSpace(part)); directive != "" {
+			directives = append(directives, directive)
+		}
+	}
+	return directives
+}
+
+// resolves href against baseURL, returns an empty string for unparsable references
+func resolveURL(baseURL *url.URL, href string) string {
+	parsed, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	return baseURL.ResolveReference(parsed).String()
+}
+
+func containsToken(tokens []string, token string) bool {
+	for _, t := range tokens {
+		if t == token {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"golang.org/x/net/html"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"webanalyzer/internal/model"
+)
+
+func TestExtractSEO(t *testing.T) {
+	baseURL, _ := url.Parse("https://example.com/products/shoes")
+
+	tests := []struct {
+		name     string
+		htmlStr  string
+		check    func(t *testing.T, seo *model.SEO)
+		warnings []string
+	}{
+		{
+			name: "Complete metadata",
+			htmlStr: `<html><head>
+				<title>Running shoes for every distance</title>
+				<meta name="description" content="Lightweight running shoes with responsive cushioning for training runs, races and everything in between.">
+				<meta name="ROBOTS" content="index, Follow">
+				<meta name="googlebot" content="max-snippet:50">
+				<link rel="canonical" href="/products/shoes#reviews">
+				<link rel="alternate" hreflang="en" href="https://example.com/products/shoes">
+				<link rel="alternate" hreflang="de" href="/de/produkte/schuhe">
+				<link rel="alternate" hreflang="x-default" href="https://example.com/products/shoes">
+				</head><body><svg><title>icon</title></svg></body></html>`,
+			check: func(t *testing.T, seo *model.SEO) {
+				if seo.Title != "Running shoes for every distance" || seo.TitleLength != 32 || seo.TitleCount != 1 {
+					t.Errorf("title = %q (%d chars, %d elements)", seo.Title, seo.TitleLength, seo.TitleCount)
+				}
+				if seo.MetaDescriptionLength != 104 {
+					t.Errorf("meta description length = %d, want 104", seo.MetaDescriptionLength)
+				}
+				if !reflect.DeepEqual(seo.MetaRobots, []string{"index", "follow"}) || !reflect.DeepEqual(seo.Googlebot, []string{"max-snippet:50"}) {
+					t.Errorf("robots = %v, googlebot = %v", seo.MetaRobots, seo.Googlebot)
+				}
+				if seo.Canonical != "https://example.com/products/shoes#reviews" || !seo.IsSelfCanonical {
+					t.Errorf("canonical = %q, self = %v", seo.Canonical, seo.IsSelfCanonical)
+				}
+				if len(seo.Hreflang) != 3 || seo.Hreflang[1] != (model.HreflangAlternate{Lang: "de", URL: "https://example.com/de/produkte/schuhe"}) {
+					t.Errorf("hreflang = %+v", seo.Hreflang)
+				}
+			},
+			warnings: []string{},
+		},
+		{
+			name:     "Missing everything",
+			htmlStr:  `<html><head></head><body></body></html>`,
+			warnings: []string{"missing title", "missing meta description", "missing canonical link"},
+		},
+		{
+			name: "Problems",
+			htmlStr: `<html><head><title></title><title>Second</title>
+				<meta name="description" content="Too short">
+				<meta name="robots" content="noindex">
+				<link rel="canonical" href="https://example.com/other">
+				<link rel="canonical" href="https://example.com/products/shoes">
+				<link rel="alternate" hreflang="en" href="/a"><link rel="alternate" hreflang="EN" href="/b">
+				</head></html>`,
+			warnings: []string{
+				`duplicate hreflang "EN"`,
+				"multiple title elements (2)",
+				"empty title",
+				"meta description is shorter than 50 characters",
+				"page is marked noindex",
+				"multiple canonical links (2)",
+				"canonical URL points to a different page",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := html.Parse(strings.NewReader(tt.htmlStr))
+			if err != nil {
+				t.Fatalf("Failed to parse HTML: %v", err)
+			}
+			seo := extractSEO(node, baseURL)
+			if tt.check != nil {
+				tt.check(t, seo)
+			}
+			if !reflect.DeepEqual(seo.Warnings, tt.warnings) {
+				t.Errorf("extractSEO() warnings = %q, want %q", seo.Warnings, tt.warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/sitemap"
+	"webanalyzer/internal/util/analyzer"
+)
+
+var ErrNoSitemap = errors.New("no sitemap found")
+
+// AuditSitemap loads the sitemaps of the site at targetURL (or the given sitemapURL) and analyzes every listed URL.
+// URLs that do not return 200, redirect, or are not linked from any of the analyzed pages are flagged in the report
+func AuditSitemap(ctx context.Context, targetURL, sitemapURL string, maxURLs int, opts model.AnalyzeOptions) (*model.SitemapReport, error) {
+	site, err := url.Parse(targetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxURLs <= 0 {
+		maxURLs = analyzer.DefaultSitemapURLs
+	}
+	if maxURLs > analyzer.MaxSitemapURLs {
+		maxURLs = analyzer.MaxSitemapURLs
+	}
+
+	sitemapURLs := []string{sitemapURL}
+	if sitemapURL == "" {
+		sitemapURLs = discoverSitemaps(ctx, site)
+	}
+
+	client := &http.Client{Timeout: analyzer.SitemapFetchTimeout, Transport: currentTransport()}
+	loaded := sitemap.Load(ctx, client, analyzer.UserAgent, sitemapURLs, maxURLs)
+	if len(loaded.Sitemaps) == 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return &model.SitemapReport{SitemapErrors: loaded.Errors}, ErrNoSitemap
+	}
+
+	report := &model.SitemapReport{
+		Sitemaps:      loaded.Sitemaps,
+		SitemapErrors: loaded.Errors,
+		URLCount:      len(loaded.URLs),
+		Truncated:     loaded.Truncated,
+		URLs:          auditSitemapURLs(ctx, loaded.URLs, opts),
+	}
+
+	linked := make(map[string]bool)
+	for _, result := range report.URLs {
+		if result.Analysis == nil {
+			continue
+		}
+		for _, link := range result.Analysis.Links {
+			if link.IsInternal {
+				linked[normalizeLinkURL(link.ResolvedURL)] = true
+			}
+		}
+		if !opts.IncludeLinks {
+			result.Analysis.Links = nil
+		}
+	}
+
+	for i := range report.URLs {
+		result := &report.URLs[i]
+		result.LinkedInternally = linked[normalizeLinkURL(result.URL)]
+		if result.StatusCode != http.StatusOK {
+			report.NonOKURLs = append(report.NonOKURLs, result.URL)
+		}
+		if result.Redirected {
+			report.RedirectedURLs = append(report.RedirectedURLs, result.URL)
+		}
+		if !result.LinkedInternally {
+			report.UnlinkedURLs = append(report.UnlinkedURLs, result.URL)
+		}
+	}
+
+	return report, ctx.Err()
+}
+
+// returns the sitemaps announced in robots.txt, or the conventional /sitemap.xml when there are none
+func discoverSitemaps(ctx context.Context, site *url.URL) []string {
+	if sitemaps := currentRobotsRules().Get(ctx, site).Sitemaps; len(sitemaps) > 0 {
+		return sitemaps
+	}
+	return []string{site.Scheme + "://" + site.Host + "/sitemap.xml"}
+}
+
+// checks and analyzes the sitemap URLs with a bounded pool of workers, results keep the order of entries
+func auditSitemapURLs(ctx context.Context, entries []sitemap.Entry, opts model.AnalyzeOptions) []model.SitemapURLResult {
+	results := make([]model.SitemapURLResult, len(entries))
+	if len(entries) == 0 {
+		return results
+	}
+
+	hosts := newHostLimiter(hostRequestInterval)
+	jobs := make(chan int, len(entries))
+
+	numWorkers := analyzer.MaxBatchWorkers
+	if len(entries) < numWorkers {
+		numWorkers = len(entries)
+	}
+
+	pageOpts := opts
+	pageOpts.IncludeLinks = true
+
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = auditSitemapURL(ctx, hosts, entries[idx], pageOpts)
+			}
+		}()
+	}
+
+	for i := range entries {
+		jobs <- i
+	}
+	close(jobs)
+
+	wg.Wait()
+
+	return results
+}
+
+// checks the status of a single sitemap URL and analyzes it when it is reachable
+func auditSitemapURL(ctx context.Context, hosts *hostLimiter, entry sitemap.Entry, opts model.AnalyzeOptions) model.SitemapURLResult {
+	result := model.SitemapURLResult{URL: entry.Loc, LastMod: entry.LastMod}
+
+	parsed, err := url.Parse(entry.Loc)
+	if err != nil || normalizeCrawlURL(parsed) == "" {
+		result.Error = errInvalidURL.Error()
+		return result
+	}
+
+	if err := waitForHost(ctx, hosts, parsed, opts); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	check := checkLinkAccessibility(ctx, nil, entry.Loc, parsed, opts)
+	result.StatusCode = check.StatusCode
+	result.FinalURL = check.FinalURL
+	result.Redirected = check.FinalURL != "" && normalizeLinkURL(check.FinalURL) != normalizeLinkURL(entry.Loc)
+	if check.Skipped || !check.IsAccessible {
+		result.Error = check.ErrorClass
+		return result
+	}
+
+	analysis, err := AnalyzePage(ctx, entry.Loc, opts)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Analysis = analysis
+	return result
+}
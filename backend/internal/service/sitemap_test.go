@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+	"webanalyzer/internal/log"
+	"webanalyzer/internal/model"
+)
+
+func TestAuditSitemap(t *testing.T) {
+	log.Logger = zap.NewNop()
+	defer func(interval time.Duration) { hostRequestInterval = interval }(hostRequestInterval)
+	hostRequestInterval = time.Millisecond
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprintf(w, "User-agent: *\nSitemap: %s/custom-sitemap.xml\n", server.URL)
+		case "/custom-sitemap.xml":
+			fmt.Fprintf(w, `<urlset><url><loc>%[1]s/</loc></url><url><loc>%[1]s/about</loc></url><url><loc>%[1]s/old</loc></url><url><loc>%[1]s/gone</loc></url></urlset>`, server.URL)
+		case "/":
+			fmt.Fprint(w, `<html><body><a href="/about">About</a></body></html>`)
+		case "/about":
+			fmt.Fprint(w, `<html><body><a href="/">Home</a></body></html>`)
+		case "/old":
+			http.Redirect(w, r, "/", http.StatusMovedPermanently)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	report, err := AuditSitemap(context.Background(), server.URL, "", 0, model.AnalyzeOptions{})
+	if err != nil {
+		t.Fatalf("AuditSitemap() unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(report.Sitemaps, []string{server.URL + "/custom-sitemap.xml"}) {
+		t.Errorf("Sitemaps = %v", report.Sitemaps)
+	}
+	if report.URLCount != 4 || len(report.URLs) != 4 {
+		t.Fatalf("URLCount = %d, want 4", report.URLCount)
+	}
+	if !reflect.DeepEqual(report.NonOKURLs, []string{server.URL + "/gone"}) {
+		t.Errorf("NonOKURLs = %v", report.NonOKURLs)
+	}
+	if !reflect.DeepEqual(report.RedirectedURLs, []string{server.URL + "/old"}) {
+		t.Errorf("RedirectedURLs = %v", report.RedirectedURLs)
+	}
+	if !reflect.DeepEqual(report.UnlinkedURLs, []string{server.URL + "/old", server.URL + "/gone"}) {
+		t.Errorf("UnlinkedURLs = %v", report.UnlinkedURLs)
+	}
+	if report.URLs[0].Analysis == nil || report.URLs[0].Analysis.Links != nil {
+		t.Errorf("URLs[0].Analysis = %+v, want analysis without links", report.URLs[0].Analysis)
+	}
+
+	if _, err := AuditSitemap(context.Background(), server.URL, server.URL+"/none.xml", 0, model.AnalyzeOptions{}); err != ErrNoSitemap {
+		t.Errorf("AuditSitemap() with missing sitemap error = %v, want %v", err, ErrNoSitemap)
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"golang.org/x/net/html"
+	"net/url"
+	"strings"
+	"sync"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/util/analyzer"
+)
+
+// properties required by the Open Graph protocol
+var requiredOpenGraph = []string{"og:title", "og:type", "og:image", "og:url"}
+
+// extracts the og:* and twitter:* meta tags of the page, image URLs are resolved against baseURL
+func extractSocialMetadata(root *html.Node, baseURL *url.URL) *model.SocialMetadata {
+	social := &model.SocialMetadata{
+		OpenGraph:        model.OpenGraph{Images: []model.SocialImage{}},
+		MissingOpenGraph: []string{},
+		MissingTwitter:   []string{},
+	}
+	og := &social.OpenGraph
+	tw := &social.Twitter
+	found := make(map[string]bool)
+
+	var visitNode func(*html.Node)
+	visitNode = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Data == "meta" {
+			property := strings.ToLower(strings.TrimSpace(analyzer.GetAttr(node, "property")))
+			if property == "" {
+				property = strings.ToLower(strings.TrimSpace(analyzer.GetAttr(node, "name")))
+			}
+			content := strings.TrimSpace(analyzer.GetAttr(node, "content"))
+			if content != "" {
+				found[property] = true
+				applySocialProperty(social, property, content, baseURL)
+			}
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			visitNode(child)
+		}
+	}
+	visitNode(root)
+
+	for _, property := range requiredOpenGraph {
+		if !found[property] && !(property == "og:image" && found["og:image:url"]) {
+			social.MissingOpenGraph = append(social.MissingOpenGraph, property)
+		}
+	}
+
+	// twitter falls back to the Open Graph title, description and image
+	if tw.Card == "" {
+		social.MissingTwitter = append(social.MissingTwitter, "twitter:card")
+	}
+	if tw.Title == "" && og.Title == "" {
+		social.MissingTwitter = append(social.MissingTwitter, "twitter:title")
+	}
+	if (tw.Card == "summary_large_image" || tw.Card == "summary") && tw.Image == nil && len(og.Images) == 0 {
+		social.MissingTwitter = append(social.MissingTwitter, "twitter:image")
+	}
+
+	return social
+}
+
+// stores a single og:* or twitter:* property, structured og:image properties describe the last og:image
+func applySocialProperty(social *model.SocialMetadata, property, content string, baseURL *url.URL) {
+	og := &social.OpenGraph
+	tw := &social.Twitter
+
+	lastImage := func() *model.SocialImage {
+		if len(og.Images) == 0 {
+			return nil
+		}
+		return &og.Images[len(og.Images)-1]
+	}
+
+	switch property {
+	case "og:title":
+		og.Title = content
+	case "og:type":
+		og.Type = content
+	case "og:url":
+		og.URL = resolveURL(baseURL, content)
+	case "og:description":
+		og.Description = content
+	case "og:site_name":
+		og.SiteName = content
+	case "og:locale":
+		og.Locale = content
+	case "og:image", "og:image:url":
+		if image := lastImage(); property == "og:image:url" && image != nil && image.URL == "" {
+			image.URL = resolveURL(baseURL, content)
+			return
+		}
+		og.Images = append(og.Images, model.SocialImage{URL: resolveURL(baseURL, content)})
+	case "og:image:secure_url", "og:image:type", "og:image:width", "og:image:height", "og:image:alt":
+		image := lastImage()
+		if image == nil {
+			og.Images = append(og.Images, model.SocialImage{})
+			image = lastImage()
+		}
+		switch property {
+		case "og:image:secure_url":
+			image.SecureURL = resolveURL(baseURL, content)
+		case "og:image:type":
+			image.Type = content
+		case "og:image:width":
+			image.Width = content
+		case "og:image:height":
+			image.Height = content
+		case "og:image:alt":
+			image.Alt = content
+		}
+	case "twitter:card":
+		tw.Card = content
+	case "twitter:site":
+		tw.Site = content
+	case "twitter:creator":
+		tw.Creator = content
+	case "twitter:title":
+		tw.Title = content
+	case "twitter:description":
+		tw.Description = content
+	case "twitter:image", "twitter:image:src":
+		if tw.Image == nil {
+			tw.Image = &model.SocialImage{}
+		}
+		tw.Image.URL = resolveURL(baseURL, content)
+	case "twitter:image:alt":
+		if tw.Image == nil {
+			tw.Image = &model.SocialImage{}
+		}
+		tw.Image.Alt = content
+	}
+}
+
+// checks that every referenced social image is reachable, using the same checks as page links
+func checkSocialImages(ctx context.Context, check *linkCheck, social *model.SocialMetadata, baseURL *url.URL, opts model.AnalyzeOptions) {
+	var images []*model.SocialImage
+	for i := range social.OpenGraph.Images {
+		if social.OpenGraph.Images[i].URL != "" {
+			images = append(images, &social.OpenGraph.Images[i])
+		}
+	}
+	if social.Twitter.Image != nil && social.Twitter.Image.URL != "" {
+		images = append(images, social.Twitter.Image)
+	}
+	if len(images) == 0 {
+		return
+	}
+
+	imageJobs := make(chan *model.SocialImage, len(images))
+
+	numWorkers := analyzer.MaxLinkCheckWorkers
+	if len(images) < numWorkers {
+		numWorkers = len(images)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for image := range imageJobs {
+				result := checkLinkAccessibility(ctx, check, image.URL, baseURL, opts)
+				image.IsAccessible = result.IsAccessible
+				image.Skipped = result.Skipped
+				image.StatusCode = result.StatusCode
+				image.ErrorClass = result.ErrorClass
+			}
+		}()
+	}
+
+	for _, image := range images {
+		imageJobs <- image
+	}
+	close(imageJobs)
+
+	wg.Wait()
+}
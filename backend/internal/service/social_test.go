@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"golang.org/x/net/html"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"webanalyzer/internal/log"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/util/analyzer"
+)
+
+func TestExtractSocialMetadata(t *testing.T) {
+	baseURL, _ := url.Parse("https://example.com/blog/post")
+
+	node, err := html.Parse(strings.NewReader(`<html><head>
+		<meta property="og:title" content="A post">
+		<meta property="og:type" content="article">
+		<meta property="og:image" content="/img/cover.png">
+		<meta property="og:image:width" content="1200">
+		<meta property="og:image:alt" content="Cover">
+		<meta property="og:image" content="https://cdn.example.com/second.png">
+		<meta property="og:image:type" content="image/png">
+		<meta name="twitter:card" content="summary_large_image">
+		<meta name="twitter:image" content="thumb.png">
+		<meta name="twitter:site" content="@example">
+		</head></html>`))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	social := extractSocialMetadata(node, baseURL)
+
+	expectedImages := []model.SocialImage{
+		{URL: "https://example.com/img/cover.png", Width: "1200", Alt: "Cover"},
+		{URL: "https://cdn.example.com/second.png", Type: "image/png"},
+	}
+	if !reflect.DeepEqual(social.OpenGraph.Images, expectedImages) {
+		t.Errorf("og images = %+v, want %+v", social.OpenGraph.Images, expectedImages)
+	}
+	if social.OpenGraph.Title != "A post" || social.OpenGraph.Type != "article" {
+		t.Errorf("open graph = %+v", social.OpenGraph)
+	}
+	if social.Twitter.Card != "summary_large_image" || social.Twitter.Site != "@example" || social.Twitter.Image.URL != "https://example.com/blog/thumb.png" {
+		t.Errorf("twitter = %+v", social.Twitter)
+	}
+	if !reflect.DeepEqual(social.MissingOpenGraph, []string{"og:url"}) {
+		t.Errorf("missing open graph = %v, want [og:url]", social.MissingOpenGraph)
+	}
+	if len(social.MissingTwitter) != 0 {
+		t.Errorf("missing twitter = %v, want none because of og fallbacks", social.MissingTwitter)
+	}
+
+	empty, _ := html.Parse(strings.NewReader(`<html><head></head></html>`))
+	social = extractSocialMetadata(empty, baseURL)
+	if !reflect.DeepEqual(social.MissingOpenGraph, requiredOpenGraph) || !reflect.DeepEqual(social.MissingTwitter, []string{"twitter:card", "twitter:title"}) {
+		t.Errorf("missing = %v / %v", social.MissingOpenGraph, social.MissingTwitter)
+	}
+}
+
+func TestCheckSocialImages(t *testing.T) {
+	log.Logger = zap.NewNop()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ok.png" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL)
+	social := &model.SocialMetadata{
+		OpenGraph: model.OpenGraph{Images: []model.SocialImage{{URL: server.URL + "/ok.png"}, {URL: server.URL + "/missing.png"}}},
+		Twitter:   model.TwitterCard{Image: &model.SocialImage{URL: server.URL + "/ok.png"}},
+	}
+
+	checkSocialImages(context.Background(), nil, social, baseURL, model.AnalyzeOptions{})
+
+	if image := social.OpenGraph.Images[0]; !image.IsAccessible || image.StatusCode != http.StatusOK {
+		t.Errorf("reachable image = %+v", image)
+	}
+	if image := social.OpenGraph.Images[1]; image.IsAccessible || image.StatusCode != http.StatusNotFound {
+		t.Errorf("missing image = %+v", image)
+	}
+	if !social.Twitter.Image.IsAccessible {
+		t.Errorf("twitter image = %+v", social.Twitter.Image)
+	}
+
+	// an image the Crawl-delay leaves no time for is reported as skipped rather than inaccessible
+	hosts := newHostLimiter(time.Hour)
+	hosts.limiter(baseURL.Host).Allow()
+	delayed := &model.SocialMetadata{Twitter: model.TwitterCard{Image: &model.SocialImage{URL: server.URL + "/ok.png"}}}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	checkSocialImages(ctx, &linkCheck{hosts: hosts, scope: baseURL}, delayed, baseURL, model.AnalyzeOptions{})
+	if image := delayed.Twitter.Image; !image.IsAccessible || !image.Skipped || image.ErrorClass != analyzer.LinkErrorCrawlDelay {
+		t.Errorf("delayed image = %+v, want skipped because of the Crawl-delay", image)
+	}
+}
+
+func TestCheckSocialImagesBounded(t *testing.T) {
+	log.Logger = zap.NewNop()
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL)
+	social := &model.SocialMetadata{}
+	for i := 0; i < 2*analyzer.MaxLinkCheckWorkers; i++ {
+		social.OpenGraph.Images = append(social.OpenGraph.Images, model.SocialImage{URL: fmt.Sprintf("%s/%d.png", server.URL, i)})
+	}
+
+	checkSocialImages(context.Background(), nil, social, baseURL, model.AnalyzeOptions{})
+
+	for _, image := range social.OpenGraph.Images {
+		if !image.IsAccessible {
+			t.Errorf("image = %+v, want accessible", image)
+		}
+	}
+	if maxInFlight > analyzer.MaxLinkCheckWorkers {
+		t.Errorf("%d images checked at once, want at most %d", maxInFlight, analyzer.MaxLinkCheckWorkers)
+	}
+}
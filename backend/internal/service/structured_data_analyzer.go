@@ -0,0 +1,337 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"golang.org/x/net/html"
+	"sort"
+	"strings"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/util/analyzer"
+)
+
+const (
+	formatJSONLD    = "json-ld"
+	formatMicrodata = "microdata"
+	formatRDFa      = "rdfa"
+)
+
+// extracts JSON-LD blocks, top level Microdata items and RDFa resources into normalized entities
+func extractStructuredData(root *html.Node) *model.StructuredData {
+	data := &model.StructuredData{
+		Entities: []model.StructuredEntity{},
+		Types:    []string{},
+		Errors:   []model.StructuredDataError{},
+	}
+	types := make(map[string]bool)
+	block := 0
+
+	var visitNode func(*html.Node)
+	visitNode = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			switch {
+			case node.Data == "script" && strings.EqualFold(strings.TrimSpace(analyzer.GetAttr(node, "type")), "application/ld+json"):
+				entities, err := parseJSONLD(analyzer.ExtractInnerText(node), block, types)
+				if err != nil {
+					data.Errors = append(data.Errors, *err)
+				}
+				data.Entities = append(data.Entities, entities...)
+				block++
+				return
+			case analyzer.HasAttr(node, "itemscope") && !analyzer.HasAttr(node, "itemprop"):
+				data.Entities = append(data.Entities, parseMicrodataItem(node, types))
+			case analyzer.HasAttr(node, "typeof") && !(analyzer.HasAttr(node, "property") && hasRDFaParent(node)):
+				data.Entities = append(data.Entities, parseRDFaResource(node, types))
+			}
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			visitNode(child)
+		}
+	}
+	visitNode(root)
+
+	for t := range types {
+		data.Types = append(data.Types, t)
+	}
+	sort.Strings(data.Types)
+
+	return data
+}
+
+// parses a JSON-LD block, a top level array or @graph yields one entity per object
+func parseJSONLD(content string, block int, types map[string]bool) ([]model.StructuredEntity, *model.StructuredDataError) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, jsonLDError(content, block, err)
+	}
+
+	var objects []map[string]interface{}
+	var collect func(value interface{})
+	collect = func(value interface{}) {
+		switch v := value.(type) {
+		case []interface{}:
+			for _, item := range v {
+				collect(item)
+			}
+		case map[string]interface{}:
+			if graph, ok := v["@graph"]; ok {
+				collect(graph)
+				return
+			}
+			objects = append(objects, v)
+		}
+	}
+	collect(doc)
+
+	entities := make([]model.StructuredEntity, 0, len(objects))
+	for _, object := range objects {
+		entity := model.StructuredEntity{
+			Format:     formatJSONLD,
+			Types:      jsonLDTypes(object["@type"]),
+			Properties: make(map[string]interface{}),
+		}
+		if id, ok := object["@id"].(string); ok {
+			entity.ID = id
+		}
+		for key, value := range object {
+			if key == "@context" || key == "@type" || key == "@id" {
+				continue
+			}
+			entity.Properties[key] = value
+		}
+		collectJSONLDTypes(object, types)
+		entities = append(entities, entity)
+	}
+	return entities, nil
+}
+
+// builds the error report of a malformed JSON-LD block with the line and column of the problem
+func jsonLDError(content string, block int, err error) *model.StructuredDataError {
+	result := &model.StructuredDataError{Format: formatJSONLD, Block: block, Message: err.Error()}
+
+	offset := int64(-1)
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		// the offset counts the offending byte as already read
+		offset = syntaxErr.Offset - 1
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	}
+	if offset < 0 || offset > int64(len(content)) {
+		return result
+	}
+
+	before := []byte(content[:offset])
+	result.Line = bytes.Count(before, []byte("\n")) + 1
+	result.Column = len(before) - bytes.LastIndexByte(before, '\n')
+	return result
+}
+
+func jsonLDTypes(value interface{}) []string {
+	var types []string
+	switch v := value.(type) {
+	case string:
+		types = append(types, normalizeSchemaType(v))
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				types = append(types, normalizeSchemaType(s))
+			}
+		}
+	}
+	return types
+}
+
+// records the @type of the value and all nested objects
+func collectJSONLDTypes(value interface{}, types map[string]bool) {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			collectJSONLDTypes(item, types)
+		}
+	case map[string]interface{}:
+		for _, t := range jsonLDTypes(v["@type"]) {
+			types[t] = true
+		}
+		for key, item := range v {
+			if key != "@type" {
+				collectJSONLDTypes(item, types)
+			}
+		}
+	}
+}
+
+// parses a Microdata item and the properties of its descendants, nested items become nested property values
+func parseMicrodataItem(node *html.Node, types map[string]bool) model.StructuredEntity {
+	entity := model.StructuredEntity{
+		Format:     formatMicrodata,
+		Types:      splitSchemaTypes(analyzer.GetAttr(node, "itemtype"), types),
+		ID:         analyzer.GetAttr(node, "itemid"),
+		Properties: make(map[string]interface{}),
+	}
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			names := strings.Fields(analyzer.GetAttr(child, "itemprop"))
+			if len(names) == 0 {
+				if !analyzer.HasAttr(child, "itemscope") {
+					walk(child)
+				}
+				continue
+			}
+
+			var value interface{}
+			if analyzer.HasAttr(child, "itemscope") {
+				value = entityValue(parseMicrodataItem(child, types))
+			} else {
+				value = microdataValue(child)
+				walk(child)
+			}
+			for _, name := range names {
+				addProperty(entity.Properties, name, value)
+			}
+		}
+	}
+	walk(node)
+
+	return entity
+}
+
+// the value of a Microdata property as defined by the element it is on
+func microdataValue(node *html.Node) string {
+	switch node.Data {
+	case "meta":
+		return analyzer.GetAttr(node, "content")
+	case "audio", "embed", "iframe", "img", "source", "track", "video":
+		return analyzer.GetAttr(node, "src")
+	case "a", "area", "link":
+		return analyzer.GetAttr(node, "href")
+	case "object":
+		return analyzer.GetAttr(node, "data")
+	case "data", "meter":
+		return analyzer.GetAttr(node, "value")
+	case "time":
+		if analyzer.HasAttr(node, "datetime") {
+			return analyzer.GetAttr(node, "datetime")
+		}
+	}
+	return strings.Join(strings.Fields(analyzer.ExtractInnerText(node)), " ")
+}
+
+// parses an RDFa resource started by a typeof attribute and the property attributes of its descendants
+func parseRDFaResource(node *html.Node, types map[string]bool) model.StructuredEntity {
+	entity := model.StructuredEntity{
+		Format:     formatRDFa,
+		Types:      splitSchemaTypes(analyzer.GetAttr(node, "typeof"), types),
+		ID:         analyzer.GetAttr(node, "resource"),
+		Properties: make(map[string]interface{}),
+	}
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			names := strings.Fields(analyzer.GetAttr(child, "property"))
+			if len(names) == 0 {
+				if !analyzer.HasAttr(child, "typeof") {
+					walk(child)
+				}
+				continue
+			}
+
+			var value interface{}
+			if analyzer.HasAttr(child, "typeof") {
+				value = entityValue(parseRDFaResource(child, types))
+			} else {
+				value = rdfaValue(child)
+				walk(child)
+			}
+			for _, name := range names {
+				addProperty(entity.Properties, normalizeSchemaType(name), value)
+			}
+		}
+	}
+	walk(node)
+
+	return entity
+}
+
+// the value of an RDFa property, preferring content over links over text
+func rdfaValue(node *html.Node) string {
+	for _, attr := range []string{"content", "href", "src", "resource"} {
+		if analyzer.HasAttr(node, attr) {
+			return analyzer.GetAttr(node, attr)
+		}
+	}
+	return strings.Join(strings.Fields(analyzer.ExtractInnerText(node)), " ")
+}
+
+// reports whether the node is inside another RDFa resource, resources used as a property value are extracted as nested properties
+func hasRDFaParent(node *html.Node) bool {
+	for p := node.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && analyzer.HasAttr(p, "typeof") {
+			return true
+		}
+	}
+	return false
+}
+
+// converts a nested entity into a property value
+func entityValue(entity model.StructuredEntity) map[string]interface{} {
+	value := make(map[string]interface{}, len(entity.Properties)+2)
+	for key, v := range entity.Properties {
+		value[key] = v
+	}
+	if len(entity.Types) > 0 {
+		value["@type"] = entity.Types
+	}
+	if entity.ID != "" {
+		value["@id"] = entity.ID
+	}
+	return value
+}
+
+// adds a property value, repeated properties are collected into a list
+func addProperty(properties map[string]interface{}, name string, value interface{}) {
+	existing, ok := properties[name]
+	if !ok {
+		properties[name] = value
+		return
+	}
+	if list, ok := existing.([]interface{}); ok {
+		properties[name] = append(list, value)
+		return
+	}
+	properties[name] = []interface{}{existing, value}
+}
+
+// splits a space separated list of types, normalizes and records them
+func splitSchemaTypes(value string, types map[string]bool) []string {
+	var result []string
+	for _, t := range strings.Fields(value) {
+		t = normalizeSchemaType(t)
+		types[t] = true
+		result = append(result, t)
+	}
+	return result
+}
+
+// strips the schema.org vocabulary from a type or property, e.g. "https://schema.org/Product" becomes "Product"
+func normalizeSchemaType(value string) string {
+	for _, prefix := range []string{"http://schema.org/", "https://schema.org/", "schema:"} {
+		if strings.HasPrefix(value, prefix) {
+			return strings.TrimPrefix(value, prefix)
+		}
+	}
+	return value
+}
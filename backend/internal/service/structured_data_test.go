@@ -0,0 +1,122 @@
+package service
+
+import (
+	"golang.org/x/net/html"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractStructuredData(t *testing.T) {
+	node, err := html.Parse(strings.NewReader(`<html><head>
+<script type="application/ld+json">
+{
+  "@context": "https://schema.org",
+  "@type": "Product",
+  "name": "Shoe",
+  "offers": {"@type": "Offer", "price": "59.99"}
+}
+</script>
+<script type="application/ld+json">
+{"@context": "https://schema.org", "@graph": [{"@type": "Organization", "@id": "#org"}, {"@type": ["WebSite", "Thing"]}]}
+</script>
+<script type="application/ld+json">
+{
+  "@type": "Article",
+  "headline": "Broken"
+  "author": "Nobody"
+}
+</script>
+</head><body>
+<div itemscope itemtype="https://schema.org/Article" itemid="urn:article:1">
+	<h1 itemprop="headline">Hello   world</h1>
+	<time itemprop="datePublished" datetime="2024-05-01">May 1</time>
+	<a itemprop="url" href="/hello">link</a>
+	<div itemprop="author" itemscope itemtype="https://schema.org/Person"><span itemprop="name">Ann</span></div>
+	<span itemprop="keywords">a</span><span itemprop="keywords">b</span>
+</div>
+<div vocab="https://schema.org/" typeof="Event">
+	<span property="name">Concert</span>
+	<div property="location" typeof="Place"><span property="name">Hall</span></div>
+	<meta property="startDate" content="2024-06-01">
+</div>
+</body></html>`))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	data := extractStructuredData(node)
+
+	expectedTypes := []string{"Article", "Event", "Offer", "Organization", "Person", "Place", "Product", "Thing", "WebSite"}
+	if !reflect.DeepEqual(data.Types, expectedTypes) {
+		t.Errorf("Types = %v, want %v", data.Types, expectedTypes)
+	}
+
+	if len(data.Entities) != 5 {
+		t.Fatalf("got %d entities, want 5: %+v", len(data.Entities), data.Entities)
+	}
+
+	product := data.Entities[0]
+	if product.Format != formatJSONLD || !reflect.DeepEqual(product.Types, []string{"Product"}) || product.Properties["name"] != "Shoe" {
+		t.Errorf("product = %+v", product)
+	}
+	if _, ok := product.Properties["@context"]; ok {
+		t.Error("@context should not be reported as a property")
+	}
+	if org := data.Entities[1]; org.ID != "#org" || !reflect.DeepEqual(org.Types, []string{"Organization"}) {
+		t.Errorf("graph entity = %+v", org)
+	}
+
+	article := data.Entities[3]
+	expectedArticle := map[string]interface{}{
+		"headline":      "Hello world",
+		"datePublished": "2024-05-01",
+		"url":           "/hello",
+		"author":        map[string]interface{}{"name": "Ann", "@type": []string{"Person"}},
+		"keywords":      []interface{}{"a", "b"},
+	}
+	if article.Format != formatMicrodata || article.ID != "urn:article:1" || !reflect.DeepEqual(article.Properties, expectedArticle) {
+		t.Errorf("microdata article = %+v", article)
+	}
+
+	event := data.Entities[4]
+	expectedEvent := map[string]interface{}{
+		"name":      "Concert",
+		"location":  map[string]interface{}{"name": "Hall", "@type": []string{"Place"}},
+		"startDate": "2024-06-01",
+	}
+	if event.Format != formatRDFa || !reflect.DeepEqual(event.Types, []string{"Event"}) || !reflect.DeepEqual(event.Properties, expectedEvent) {
+		t.Errorf("rdfa event = %+v", event)
+	}
+
+	if len(data.Errors) != 1 {
+		t.Fatalf("got %d errors, want 1", len(data.Errors))
+	}
+	if e := data.Errors[0]; e.Block != 2 || e.Line != 5 || e.Column != 3 {
+		t.Errorf("error = %+v, want block 2 line 5 column 3", e)
+	}
+}
+
+func TestExtractStructuredDataNestedMicrodataItem(t *testing.T) {
+	node, err := html.Parse(strings.NewReader(`<html><body>
+<div itemscope itemtype="https://schema.org/Article">
+	<h1 itemprop="headline">Hello</h1>
+	<div itemscope itemtype="https://schema.org/Comment"><span itemprop="text">Nice</span></div>
+</div>
+</body></html>`))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	data := extractStructuredData(node)
+
+	if len(data.Entities) != 2 {
+		t.Fatalf("got %d entities, want 2: %+v", len(data.Entities), data.Entities)
+	}
+	if article := data.Entities[0]; !reflect.DeepEqual(article.Properties, map[string]interface{}{"headline": "Hello"}) {
+		t.Errorf("article properties = %v, want only the headline", article.Properties)
+	}
+	if comment := data.Entities[1]; !reflect.DeepEqual(comment.Types, []string{"Comment"}) || comment.Properties["text"] != "Nice" {
+		t.Errorf("comment = %+v", comment)
+	}
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/util/analyzer"
+)
+
+var ErrTLSVerification = errors.New("TLS certificate verification failed")
+
+// describes the TLS connection and the certificate chain of the page response, nil for plain HTTP responses
+func inspectTLS(state *tls.ConnectionState, host string, now time.Time) *model.TLSInfo {
+	if state == nil {
+		return nil
+	}
+
+	info := &model.TLSInfo{
+		Version:      tls.VersionName(state.Version),
+		CipherSuite:  tls.CipherSuiteName(state.CipherSuite),
+		ALPN:         state.NegotiatedProtocol,
+		ServerName:   host,
+		Certificates: []model.CertificateInfo{},
+		Warnings:     []string{},
+	}
+	if state.Version < tls.VersionTLS12 {
+		info.Warnings = append(info.Warnings, fmt.Sprintf("%s is deprecated, use TLS 1.2 or newer", info.Version))
+	}
+
+	inspectCertificates(info, state.PeerCertificates, host, now)
+	return info
+}
+
+// describes the certificate chain of a fetch that failed its certificate verification and explains the failure,
+// the connection is gone by then so only the chain is reported. the error wraps ErrTLSVerification, nil info for other errors
+func inspectRejectedTLS(err error, now time.Time) (*model.TLSInfo, error) {
+	var certErr *tls.CertificateVerificationError
+	var urlErr *url.Error
+	if !errors.As(err, &certErr) || !errors.As(err, &urlErr) {
+		return nil, err
+	}
+	// the failed request may be a redirect to another host
+	failedURL, parseErr := url.Parse(urlErr.URL)
+	if parseErr != nil {
+		return nil, err
+	}
+
+	info := &model.TLSInfo{
+		ServerName:   failedURL.Hostname(),
+		Certificates: []model.CertificateInfo{},
+		Warnings:     []string{},
+	}
+	inspectCertificates(info, certErr.UnverifiedCertificates, info.ServerName, now)
+	if len(info.Warnings) == 0 {
+		info.Warnings = append(info.Warnings, fmt.Sprintf("certificate rejected: %v", certErr.Err))
+	}
+	return info, fmt.Errorf("%w for %s: %s", ErrTLSVerification, info.ServerName, strings.Join(info.Warnings, "; "))
+}
+
+// adds the certificates to info and warns about hostname mismatches, self-signed and expiring certificates
+func inspectCertificates(info *model.TLSInfo, certs []*x509.Certificate, host string, now time.Time) {
+	if len(certs) == 0 {
+		info.Warnings = append(info.Warnings, "server sent no certificate")
+		return
+	}
+
+	leaf := certs[0]
+	if err := leaf.VerifyHostname(host); err != nil {
+		info.Warnings = append(info.Warnings, fmt.Sprintf("certificate does not match %s", host))
+	} else {
+		info.HostnameMatch = true
+	}
+	if len(certs) == 1 && leaf.Subject.String() == leaf.Issuer.String() {
+		info.Warnings = append(info.Warnings, "certificate is self-signed")
+	}
+
+	for i, cert := range certs {
+		certInfo := newCertificateInfo(cert, now)
+		info.Certificates = append(info.Certificates, certInfo)
+		// the chain is as good as its first expiring certificate
+		if i == 0 || certInfo.DaysUntilExpiry < info.DaysUntilExpiry {
+			info.DaysUntilExpiry = certInfo.DaysUntilExpiry
+		}
+
+		switch {
+		case now.After(cert.NotAfter):
+			info.Warnings = append(info.Warnings, fmt.Sprintf("certificate %q expired on %s", certInfo.Subject, cert.NotAfter.Format(time.DateOnly)))
+		case now.Before(cert.NotBefore):
+			info.Warnings = append(info.Warnings, fmt.Sprintf("certificate %q is not valid before %s", certInfo.Subject, cert.NotBefore.Format(time.DateOnly)))
+		case certInfo.DaysUntilExpiry <= analyzer.CertExpiryWarningDays:
+			info.Warnings = append(info.Warnings, fmt.Sprintf("certificate %q expires in %d days", certInfo.Subject, certInfo.DaysUntilExpiry))
+		}
+	}
+}
+
+func newCertificateInfo(cert *x509.Certificate, now time.Time) model.CertificateInfo {
+	sans := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	return model.CertificateInfo{
+		Subject:            cert.Subject.String(),
+		Issuer:             cert.Issuer.String(),
+		SANs:               sans,
+		SerialNumber:       cert.SerialNumber.String(),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		IsCA:               cert.IsCA,
+		NotBefore:          cert.NotBefore,
+		NotAfter:           cert.NotAfter,
+		DaysUntilExpiry:    int(math.Floor(cert.NotAfter.Sub(now).Hours() / 24)),
+	}
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"webanalyzer/internal/log"
+	"webanalyzer/internal/model"
+)
+
+func TestAnalyzePageTLS(t *testing.T) {
+	log.Logger = zap.NewNop()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `<!DOCTYPE html><html><head><title>TLS</title></head></html>`)
+	}))
+	defer server.Close()
+	defer setTransport(setTransport(server.Client().Transport))
+
+	page, err := AnalyzePage(context.Background(), server.URL, model.AnalyzeOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzePage() unexpected error: %v", err)
+	}
+	info := page.Security.TLS
+	if info == nil {
+		t.Fatal("AnalyzePage() returned no TLS info for an HTTPS page")
+	}
+	if info.Version != "TLS 1.3" || info.CipherSuite == "" || !info.HostnameMatch {
+		t.Errorf("TLS info = %+v, want TLS 1.3 with a matching certificate", info)
+	}
+	if len(info.Certificates) != 1 || info.Certificates[0].DaysUntilExpiry <= 0 {
+		t.Errorf("certificates = %+v, want the valid test certificate", info.Certificates)
+	}
+	if len(info.Warnings) != 1 || info.Warnings[0] != "certificate is self-signed" {
+		t.Errorf("warnings = %v, want self-signed", info.Warnings)
+	}
+}
+
+func TestAnalyzePageRejectedCertificate(t *testing.T) {
+	log.Logger = zap.NewNop()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><title>TLS</title></head></html>`)
+	})
+	defer setTransport(currentTransport())
+
+	tests := []struct {
+		name    string
+		server  func() *httptest.Server
+		trusted bool
+		host    string
+		warning string
+	}{
+		{
+			name:    "self-signed",
+			server:  func() *httptest.Server { return httptest.NewTLSServer(handler) },
+			warning: "certificate is self-signed",
+		},
+		{
+			name:    "hostname mismatch",
+			server:  func() *httptest.Server { return httptest.NewTLSServer(handler) },
+			trusted: true,
+			host:    "localhost",
+			warning: "certificate does not match localhost",
+		},
+		{
+			name: "expired",
+			server: func() *httptest.Server {
+				cert, _ := testCertificate(t, "127.0.0.1", time.Now().Add(-90*24*time.Hour), time.Now().Add(-48*time.Hour))
+				server := httptest.NewUnstartedServer(handler)
+				server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+				server.StartTLS()
+				return server
+			},
+			trusted: true,
+			warning: `certificate "CN=127.0.0.1" expired on`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := tt.server()
+			defer server.Close()
+
+			transport := http.DefaultTransport
+			if tt.trusted {
+				pool := x509.NewCertPool()
+				for _, der := range server.TLS.Certificates[0].Certificate {
+					cert, _ := x509.ParseCertificate(der)
+					pool.AddCert(cert)
+				}
+				transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+			}
+			setTransport(transport)
+			target := server.URL
+			if tt.host != "" {
+				target = strings.Replace(target, "127.0.0.1", tt.host, 1)
+			}
+
+			page, err := AnalyzePage(context.Background(), target, model.AnalyzeOptions{})
+			if !errors.Is(err, ErrTLSVerification) {
+				t.Fatalf("AnalyzePage() error = %v, want %v", err, ErrTLSVerification)
+			}
+			if !strings.Contains(err.Error(), tt.warning) {
+				t.Errorf("AnalyzePage() error = %q, want it to mention %q", err, tt.warning)
+			}
+			if page.Security == nil || page.Security.TLS == nil || len(page.Security.TLS.Certificates) == 0 {
+				t.Fatalf("security = %+v, want the rejected certificate chain", page.Security)
+			}
+			if warnings := strings.Join(page.Security.TLS.Warnings, "|"); !strings.Contains(warnings, tt.warning) {
+				t.Errorf("TLS warnings = %q, want %q", page.Security.TLS.Warnings, tt.warning)
+			}
+		})
+	}
+}
+
+func TestInspectTLS(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name          string
+		state         *tls.ConnectionState
+		host          string
+		hostnameMatch bool
+		daysToExpiry  int
+		warnings      []string
+	}{
+		{
+			name:          "valid chain",
+			state:         &tls.ConnectionState{Version: tls.VersionTLS13, CipherSuite: tls.TLS_AES_128_GCM_SHA256, NegotiatedProtocol: "h2", PeerCertificates: testChain(t, "example.com", now.Add(-day), now.Add(90*day))},
+			host:          "www.example.com",
+			hostnameMatch: true,
+			daysToExpiry:  90,
+			warnings:      []string{},
+		},
+		{
+			name:          "expiring soon",
+			state:         &tls.ConnectionState{Version: tls.VersionTLS12, PeerCertificates: testChain(t, "example.com", now.Add(-day), now.Add(10*day+time.Hour))},
+			host:          "example.com",
+			hostnameMatch: true,
+			daysToExpiry:  10,
+			warnings:      []string{`certificate "CN=example.com" expires in 10 days`},
+		},
+		{
+			name:          "expired and mismatched",
+			state:         &tls.ConnectionState{Version: tls.VersionTLS10, PeerCertificates: testChain(t, "example.com", now.Add(-90*day), now.Add(-2*day))},
+			host:          "other.org",
+			hostnameMatch: false,
+			daysToExpiry:  -2,
+			warnings: []string{
+				"TLS 1.0 is deprecated, use TLS 1.2 or newer",
+				"certificate does not match other.org",
+				`certificate "CN=example.com" expired on 2025-12-30`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := inspectTLS(tt.state, tt.host, now)
+			if info.HostnameMatch != tt.hostnameMatch || info.DaysUntilExpiry != tt.daysToExpiry {
+				t.Errorf("inspectTLS() = %+v, want hostname match %v and %d days to expiry", info, tt.hostnameMatch, tt.daysToExpiry)
+			}
+			if strings.Join(info.Warnings, "|") != strings.Join(tt.warnings, "|") {
+				t.Errorf("inspectTLS() warnings = %q, want %q", info.Warnings, tt.warnings)
+			}
+			if len(info.Certificates) != 2 || info.Certificates[0].SANs[0] != "example.com" || !info.Certificates[1].IsCA {
+				t.Errorf("inspectTLS() certificates = %+v, want leaf and CA", info.Certificates)
+			}
+		})
+	}
+
+	if inspectTLS(nil, "example.com", now) != nil {
+		t.Error("inspectTLS() returned info for a plain HTTP response")
+	}
+}
+
+// builds a leaf certificate for domain and *.domain signed by a test CA, the CA outlives the leaf
+func testChain(t *testing.T, domain string, notBefore, notAfter time.Time) []*x509.Certificate {
+	t.Helper()
+	cert, ca := testCertificate(t, domain, notBefore, notAfter)
+	return []*x509.Certificate{cert.Leaf, ca}
+}
+
+// builds the server certificate of testChain and returns it with its CA
+func testCertificate(t *testing.T, domain string, notBefore, notAfter time.Time) (tls.Certificate, *x509.Certificate) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             notBefore.Add(-365 * 24 * time.Hour),
+		NotAfter:              notAfter.Add(365 * 24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain, "*." + domain},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(domain); ip != nil {
+		leafTemplate.DNSNames, leafTemplate.IPAddresses = nil, []net.IP{ip}
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, ca, &leafKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, _ := x509.ParseCertificate(leafDER)
+
+	return tls.Certificate{Certificate: [][]byte{leafDER, caDER}, PrivateKey: leafKey, Leaf: leaf}, ca
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"net/http"
+	"sync"
+	"webanalyzer/internal/fetcher"
+	"webanalyzer/internal/outbound"
+	"webanalyzer/internal/robots"
+)
+
+// ErrBlockedDestination is returned when the target page or one of its redirects points to a private, loopback or metadata address
+var ErrBlockedDestination = outbound.ErrBlockedAddress
+
+// fetchTransport sends every request to analyzed sites: pages, links, resources, robots.txt and sitemaps.
+// it holds the configured fetcher, tests swap it with setTransport to trust their TLS servers.
+// robotsRules caches the robots.txt rules of every host we talk to, fetched through fetchTransport
+var (
+	transportMu    sync.RWMutex
+	fetchTransport http.RoundTripper = http.DefaultTransport
+	robotsRules                      = newRobotsCache(http.DefaultTransport)
+)
+
+// SetFetcher makes f send every request to analyzed sites, it is meant to be called once at startup
+func SetFetcher(f fetcher.Fetcher) {
+	setTransport(f)
+}
+
+// replaces the transport of the requests to analyzed sites and the robots.txt cache built on it,
+// it returns the previous transport
+func setTransport(transport http.RoundTripper) http.RoundTripper {
+	rules := newRobotsCache(transport)
+
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	previous := fetchTransport
+	fetchTransport = transport
+	robotsRules = rules
+	return previous
+}
+
+func currentTransport() http.RoundTripper {
+	transportMu.RLock()
+	defer transportMu.RUnlock()
+	return fetchTransport
+}
+
+func currentRobotsRules() *robots.Cache {
+	transportMu.RLock()
+	defer transportMu.RUnlock()
+	return robotsRules
+}
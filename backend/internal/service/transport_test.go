@@ -0,0 +1,158 @@
+package service
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"webanalyzer/internal/fetcher"
+	"webanalyzer/internal/log"
+	"webanalyzer/internal/model"
+	"webanalyzer/internal/outbound"
+	"webanalyzer/internal/util/analyzer"
+)
+
+func TestAnalyzePageBlockedDestination(t *testing.T) {
+	log.Logger = zap.NewNop()
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer internal.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><body><a href="%s/admin">Admin</a></body></html>`, internal.URL)
+	}))
+	defer server.Close()
+
+	defer setTransport(currentTransport())
+	transport, err := outbound.NewTransport(outbound.Settings{BlockPrivateNetworks: true})
+	if err != nil {
+		t.Fatalf("NewTransport() unexpected error: %v", err)
+	}
+	SetFetcher(fetcher.NewLive(transport))
+
+	if _, err := AnalyzePage(context.Background(), server.URL, model.AnalyzeOptions{}); !errors.Is(err, ErrBlockedDestination) {
+		t.Errorf("AnalyzePage() error = %v, want %v", err, ErrBlockedDestination)
+	}
+
+	baseURL, _ := url.Parse(server.URL)
+	result := checkLinkAccessibility(context.Background(), nil, internal.URL+"/admin", baseURL, model.AnalyzeOptions{IgnoreRobots: true})
+	if result.IsAccessible || result.ErrorClass != analyzer.LinkErrorBlockedDestination {
+		t.Errorf("checkLinkAccessibility() = %+v, want %s", result, analyzer.LinkErrorBlockedDestination)
+	}
+}
+
+func TestAnalyzePageReplay(t *testing.T) {
+	log.Logger = zap.NewNop()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><head><title>Captured</title></head><body><a href="/about">About</a><a href="/gone">Gone</a></body></html>`)
+		case "/about":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	defer setTransport(currentTransport())
+	archive := filepath.Join(t.TempDir(), "site.har")
+	recorder, err := fetcher.New(fetcher.Settings{Mode: fetcher.ModeRecord, Archive: archive}, http.DefaultTransport)
+	if err != nil {
+		t.Fatalf("fetcher.New() unexpected error: %v", err)
+	}
+	SetFetcher(recorder)
+	recorded, err := AnalyzePage(context.Background(), server.URL, model.AnalyzeOptions{IncludeLinks: true})
+	if err != nil {
+		t.Fatalf("AnalyzePage() unexpected error: %v", err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+	server.Close()
+
+	replay, err := fetcher.New(fetcher.Settings{Mode: fetcher.ModeReplay, Archive: archive}, nil)
+	if err != nil {
+		t.Fatalf("fetcher.New() unexpected error: %v", err)
+	}
+	SetFetcher(replay)
+	replayed, err := AnalyzePage(context.Background(), server.URL, model.AnalyzeOptions{IncludeLinks: true})
+	if err != nil {
+		t.Fatalf("AnalyzePage() replay unexpected error: %v", err)
+	}
+
+	if replayed.PageTitle != recorded.PageTitle || replayed.InaccessibleLinkCount != 1 || recorded.InaccessibleLinkCount != 1 {
+		t.Errorf("replayed analysis = %q with %d inaccessible links, want %q with 1", replayed.PageTitle, replayed.InaccessibleLinkCount, recorded.PageTitle)
+	}
+	for i, link := range replayed.Links {
+		if link.StatusCode != recorded.Links[i].StatusCode {
+			t.Errorf("replayed link %s status = %d, want %d", link.Href, link.StatusCode, recorded.Links[i].StatusCode)
+		}
+	}
+}
+
+func TestAnalyzePageReplayTruncated(t *testing.T) {
+	log.Logger = zap.NewNop()
+	defer SetFetchLimits(FetchLimits{MaxBodyBytes: analyzer.DefaultMaxBodyBytes})
+	SetFetchLimits(FetchLimits{MaxBodyBytes: 256})
+
+	// random bytes do not compress, so the recorded gzip stream ends before the body limit is reached
+	random := make([]byte, 2048)
+	rand.New(rand.NewSource(1)).Read(random)
+	page := `<html><head><title>Large</title></head><body><!--` + string(random) + `--></body></html>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/gzip" {
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			defer gz.Close()
+			fmt.Fprint(gz, page)
+			return
+		}
+		fmt.Fprint(w, page)
+	}))
+	defer server.Close()
+
+	defer setTransport(currentTransport())
+	for _, name := range []string{"plain", "gzip"} {
+		t.Run(name, func(t *testing.T) {
+			path := "/" + name
+			archive := filepath.Join(t.TempDir(), "fixtures")
+			recorder, err := fetcher.New(fetcher.Settings{Mode: fetcher.ModeRecord, Archive: archive, MaxBodyBytes: 256}, http.DefaultTransport)
+			if err != nil {
+				t.Fatalf("fetcher.New() unexpected error: %v", err)
+			}
+			SetFetcher(recorder)
+			recorded, err := AnalyzePage(context.Background(), server.URL+path, model.AnalyzeOptions{})
+			if err != nil {
+				t.Fatalf("AnalyzePage() unexpected error: %v", err)
+			}
+
+			replay, err := fetcher.New(fetcher.Settings{Mode: fetcher.ModeReplay, Archive: archive}, nil)
+			if err != nil {
+				t.Fatalf("fetcher.New() unexpected error: %v", err)
+			}
+			SetFetcher(replay)
+			replayed, err := AnalyzePage(context.Background(), server.URL+path, model.AnalyzeOptions{})
+			if err != nil {
+				t.Fatalf("AnalyzePage() replay unexpected error: %v", err)
+			}
+
+			if !recorded.Content.Truncated || !replayed.Content.Truncated || len(replayed.Content.Warnings) == 0 {
+				t.Errorf("truncated = %v recorded and %v replayed with warnings %q, want the cut body reported", recorded.Content.Truncated, replayed.Content.Truncated, replayed.Content.Warnings)
+			}
+			if path == "/gzip" && !strings.Contains(strings.Join(replayed.Content.Warnings, "|"), "ended early") {
+				t.Errorf("replayed warnings = %q, want the gzip stream reported as cut", replayed.Content.Warnings)
+			}
+			if replayed.PageTitle != "Large" {
+				t.Errorf("replayed title = %q, want Large", replayed.PageTitle)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package sitemap
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// MaxSitemapSize is the largest uncompressed sitemap accepted, as defined by the sitemaps protocol
+	MaxSitemapSize = 50 * 1024 * 1024
+	// MaxIndexDepth limits how deep nested sitemap indexes are followed
+	MaxIndexDepth = 2
+)
+
+var ErrTooLarge = errors.New("sitemap exceeds the maximum size")
+
+// Entry is a single <url> of a urlset
+type Entry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// Sitemap is a parsed urlset or sitemap index
+type Sitemap struct {
+	IsIndex bool
+	// Sitemaps holds the child sitemap locations of an index
+	Sitemaps []string
+	URLs     []Entry
+}
+
+type urlSet struct {
+	URLs []Entry `xml:"url"`
+}
+
+type sitemapIndex struct {
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+// Parse reads a urlset or a sitemap index, gzip compressed content is detected and decompressed
+func Parse(r io.Reader) (*Sitemap, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress sitemap: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r, MaxSitemapSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sitemap: %w", err)
+	}
+	if len(body) > MaxSitemapSize {
+		return nil, ErrTooLarge
+	}
+
+	root, err := rootElement(body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch root {
+	case "sitemapindex":
+		var index sitemapIndex
+		if err := xml.Unmarshal(body, &index); err != nil {
+			return nil, fmt.Errorf("failed to parse sitemap index: %w", err)
+		}
+		sm := &Sitemap{IsIndex: true}
+		for _, child := range index.Sitemaps {
+			if loc := strings.TrimSpace(child.Loc); loc != "" {
+				sm.Sitemaps = append(sm.Sitemaps, loc)
+			}
+		}
+		return sm, nil
+	case "urlset":
+		var set urlSet
+		if err := xml.Unmarshal(body, &set); err != nil {
+			return nil, fmt.Errorf("failed to parse urlset: %w", err)
+		}
+		sm := &Sitemap{}
+		for _, entry := range set.URLs {
+			entry.Loc = strings.TrimSpace(entry.Loc)
+			entry.LastMod = strings.TrimSpace(entry.LastMod)
+			if entry.Loc != "" {
+				sm.URLs = append(sm.URLs, entry)
+			}
+		}
+		return sm, nil
+	default:
+		return nil, fmt.Errorf("unexpected sitemap root element <%s>", root)
+	}
+}
+
+// returns the local name of the first element of an XML document
+func rootElement(body []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", fmt.Errorf("failed to parse sitemap: %w", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+// Fetch downloads and parses the sitemap at sitemapURL
+func Fetch(ctx context.Context, client *http.Client, userAgent, sitemapURL string) (*Sitemap, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sitemapURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sitemap: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		Body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return Parse(resp.Body)
+}
+
+// Result is the outcome of loading a set of sitemaps
+type Result struct {
+	// Sitemaps lists every sitemap that was read successfully
+	Sitemaps []string
+	URLs     []Entry
+	Errors   []string
+	// Truncated is set when more than maxURLs URLs were listed
+	Truncated bool
+}
+
+// Load fetches the given sitemaps and follows sitemap indexes, collecting at most maxURLs unique URLs
+func Load(ctx context.Context, client *http.Client, userAgent string, sitemapURLs []string, maxURLs int) *Result {
+	result := &Result{}
+	seenSitemaps := make(map[string]bool)
+	seenURLs := make(map[string]bool)
+
+	var load func(sitemapURL string, depth int)
+	load = func(sitemapURL string, depth int) {
+		if seenSitemaps[sitemapURL] || result.Truncated || ctx.Err() != nil {
+			return
+		}
+		seenSitemaps[sitemapURL] = true
+
+		sm, err := Fetch(ctx, client, userAgent, sitemapURL)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", sitemapURL, err))
+			return
+		}
+		result.Sitemaps = append(result.Sitemaps, sitemapURL)
+
+		if sm.IsIndex {
+			if depth >= MaxIndexDepth {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: sitemap index nested too deep", sitemapURL))
+				return
+			}
+			for _, child := range sm.Sitemaps {
+				load(child, depth+1)
+			}
+			return
+		}
+
+		for _, entry := range sm.URLs {
+			if seenURLs[entry.Loc] {
+				continue
+			}
+			if len(result.URLs) >= maxURLs {
+				result.Truncated = true
+				return
+			}
+			seenURLs[entry.Loc] = true
+			result.URLs = append(result.URLs, entry)
+		}
+	}
+
+	for _, sitemapURL := range sitemapURLs {
+		load(sitemapURL, 0)
+	}
+
+	return result
+}
@@ -0,0 +1,97 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const urlSetXML = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<url><loc> https://example.com/ </loc><lastmod>2024-01-01</lastmod></url>
+	<url><loc>https://example.com/about</loc></url>
+	<url><loc></loc></url>
+</urlset>`
+
+func gzipped(t *testing.T, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParse(t *testing.T) {
+	for name, body := range map[string][]byte{
+		"plain":   []byte(urlSetXML),
+		"gzipped": gzipped(t, urlSetXML),
+	} {
+		t.Run(name, func(t *testing.T) {
+			sm, err := Parse(bytes.NewReader(body))
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			if sm.IsIndex || len(sm.URLs) != 2 {
+				t.Fatalf("Parse() = %+v, want urlset with 2 urls", sm)
+			}
+			if sm.URLs[0] != (Entry{Loc: "https://example.com/", LastMod: "2024-01-01"}) {
+				t.Errorf("URLs[0] = %+v", sm.URLs[0])
+			}
+		})
+	}
+
+	index, err := Parse(strings.NewReader(`<sitemapindex><sitemap><loc>https://example.com/a.xml</loc></sitemap><sitemap><loc>https://example.com/b.xml.gz</loc></sitemap></sitemapindex>`))
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	if !index.IsIndex || len(index.Sitemaps) != 2 || index.Sitemaps[1] != "https://example.com/b.xml.gz" {
+		t.Errorf("Parse() index = %+v", index)
+	}
+
+	if _, err := Parse(strings.NewReader(`<html><body>not a sitemap</body></html>`)); err == nil {
+		t.Error("Parse() expected error for html document")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.xml":
+			fmt.Fprintf(w, `<sitemapindex><sitemap><loc>%[1]s/pages.xml.gz</loc></sitemap><sitemap><loc>%[1]s/posts.xml</loc></sitemap><sitemap><loc>%[1]s/missing.xml</loc></sitemap></sitemapindex>`, server.URL)
+		case "/pages.xml.gz":
+			w.Write(gzipped(t, `<urlset><url><loc>https://example.com/a</loc></url><url><loc>https://example.com/b</loc></url></urlset>`))
+		case "/posts.xml":
+			fmt.Fprint(w, `<urlset><url><loc>https://example.com/b</loc></url><url><loc>https://example.com/c</loc></url><url><loc>https://example.com/d</loc></url></urlset>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	result := Load(context.Background(), server.Client(), "TestBot", []string{server.URL + "/index.xml"}, 3)
+
+	if len(result.Sitemaps) != 3 {
+		t.Errorf("Sitemaps = %v, want index and two children", result.Sitemaps)
+	}
+	var locs []string
+	for _, entry := range result.URLs {
+		locs = append(locs, entry.Loc)
+	}
+	if strings.Join(locs, ",") != "https://example.com/a,https://example.com/b,https://example.com/c" {
+		t.Errorf("URLs = %v", locs)
+	}
+	if !result.Truncated {
+		t.Error("expected result to be truncated at 3 urls")
+	}
+}
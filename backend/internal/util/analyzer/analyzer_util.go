@@ -1,26 +1,134 @@
 package analyzer
 
 import (
+	"fmt"
 	"golang.org/x/net/html"
-	"regexp"
 	"strings"
 	"time"
 )
 
-var (
-	Html5Doctype = regexp.MustCompile(`(?i)<!DOCTYPE\s+html>`)
-	Html4Doctype = regexp.MustCompile(`(?i)<!DOCTYPE\s+HTML\s+PUBLIC\s+"[^"]*//DTD\s+HTML\s+4`)
-	XhtmlDoctype = regexp.MustCompile(`(?i)<!DOCTYPE\s+html\s+PUBLIC\s+"[^"]*//DTD\s+XHTML`)
-)
+// Link is an <a href> reference together with its anchor text
+type Link struct {
+	Href string
+	Text string
+}
 
 type LinkResult struct {
 	IsInternal   bool
 	IsAccessible bool
+	Skipped      bool
+	StatusCode   int
+	FinalURL     string
+	ErrorClass   string
+	Latency      time.Duration
+	// -1 when the response did not tell the size
+	ContentLength int64
 }
 
 const (
 	MaxLinkCheckWorkers = 20
 	LinkCheckTimeout    = 5 * time.Second
+	MaxBatchURLs        = 500
+	MaxBatchWorkers     = 8
+	HostRequestInterval = 1 * time.Second
+	MaxCrawlWorkers     = 4
+	DefaultCrawlDepth   = 2
+	MaxCrawlDepth       = 5
+	DefaultCrawlPages   = 50
+	MaxCrawlPages       = 500
+	RobotsCacheTTL      = 1 * time.Hour
+	RobotsCacheSize     = 1000
+	MaxCrawlDelay       = 30 * time.Second
+	DefaultSitemapURLs  = 100
+	MaxSitemapURLs      = 500
+	SitemapFetchTimeout = 30 * time.Second
+	MaxRedirects        = 10
+)
+
+// recommended lengths of the title and meta description, in characters
+const (
+	MinTitleLength           = 10
+	MaxTitleLength           = 60
+	MinMetaDescriptionLength = 50
+	MaxMetaDescriptionLength = 160
+)
+
+// rendering modes a browser picks from the doctype of a page
+const (
+	RenderingModeStandards       = "standards"
+	RenderingModeAlmostStandards = "almost-standards"
+	RenderingModeQuirks          = "quirks"
+)
+
+// types of the subresources in the resource inventory
+const (
+	ResourceScript     = "script"
+	ResourceStylesheet = "stylesheet"
+	ResourcePreload    = "preload"
+	ResourceIcon       = "icon"
+	ResourceFont       = "font"
+	ResourceImage      = "image"
+	ResourceMedia      = "media"
+	ResourceIframe     = "iframe"
+	ResourceObject     = "object"
+)
+
+// categories of mixed content
+const (
+	MixedContentActive  = "active"
+	MixedContentPassive = "passive"
+	MixedContentForm    = "form"
+)
+
+// shortest Strict-Transport-Security max-age considered strong enough, 180 days
+const MinHSTSMaxAgeSeconds = 180 * 24 * 60 * 60
+
+// certificates expiring within this many days are reported
+const CertExpiryWarningDays = 30
+
+// decoded bytes of the analyzed page read unless MAX_BODY_BYTES says otherwise, 10 MiB
+const DefaultMaxBodyBytes = 10 << 20
+
+// a compressed body growing more than MaxCompressionRatio times its transfer size is treated as a decompression bomb
+// once DecompressionCheckBytes have been decoded
+const (
+	MaxCompressionRatio     = 100
+	DecompressionCheckBytes = 1 << 20
+)
+
+// where the character encoding of the page was taken from, in order of precedence
+const (
+	EncodingSourceBOM     = "bom"
+	EncodingSourceHeader  = "header"
+	EncodingSourceMeta    = "meta"
+	EncodingSourceDefault = "default"
+)
+
+// number of bytes searched for a <meta> charset declaration, as in the HTML prescan
+const MetaPrescanBytes = 1024
+
+// headers callers may set on the requests to the analyzed host unless ALLOWED_REQUEST_HEADERS says otherwise
+var DefaultAllowedRequestHeaders = []string{"Authorization", "Accept", "Accept-Language", "Cache-Control", "Referer"}
+
+// UserAgent is sent with every outgoing request and matched against robots.txt groups
+const UserAgent = "WebAnalyzer/1.0 (+https://github.com/sahan-thinusha/webanalyzer)"
+
+// error classes reported for links that could not be checked successfully
+const (
+	LinkErrorInvalidURL        = "invalid_url"
+	LinkErrorTimeout           = "timeout"
+	LinkErrorDNS               = "dns"
+	LinkErrorConnectionRefused = "connection_refused"
+	LinkErrorTLS               = "tls"
+	LinkErrorNetwork           = "network"
+	LinkErrorClientError       = "http_4xx"
+	LinkErrorServerError       = "http_5xx"
+	// reported for skipped links that robots.txt does not allow us to check
+	LinkErrorRobotsDisallowed = "robots_disallowed"
+	// reported for links to private, loopback or metadata addresses
+	LinkErrorBlockedDestination = "blocked_destination"
+	// reported for skipped links to the analyzed host that the Crawl-delay left no time to check
+	LinkErrorCrawlDelay = "crawl_delay"
 )
 
 // ExtractInnerText extracts all visible text content inside a node.
@@ -44,6 +152,26 @@ func IsLinkTag(node *html.Node) bool {
 	return node.Type == html.ElementNode && node.Data == "a"
 }
 
+// GetAttr returns the value of the named attribute, or an empty string when it is missing
+func GetAttr(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// HasAttr reports whether the node has the named attribute, even if it is empty
+func HasAttr(node *html.Node, key string) bool {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
 // GetHrefValue finds and returns the href attribute from an <a> tag.
 // If there's no href, it returns an empty string.
 func GetHrefValue(node *html.Node) string {
@@ -54,3 +182,81 @@ func GetHrefValue(node *html.Node) string {
 	}
 	return ""
 }
+
+// CSSSelector builds a CSS selector that locates the element in the document,
+// e.g. "html > body > div#main > ul > li:nth-of-type(3) > a".
+// the path starts at the closest ancestor with an id unique in the document when there is one,
+// ids holds the number of elements carrying each id as returned by CountIDs
+func CSSSelector(node *html.Node, ids map[string]int) string {
+	var parts []string
+	for n := node; n != nil && n.Type == html.ElementNode; n = n.Parent {
+		// a duplicated id would select the first element carrying it
+		if id := GetAttr(n, "id"); id != "" && ids[id] == 1 {
+			parts = append(parts, n.Data+"#"+cssEscape(id))
+			break
+		}
+
+		part := n.Data
+		index, total := 0, 0
+		if n.Parent != nil {
+			for sibling := n.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+				if sibling.Type == html.ElementNode && sibling.Data == n.Data {
+					total++
+					if sibling == n {
+						index = total
+					}
+				}
+			}
+		}
+		if total > 1 {
+			part += fmt.Sprintf(":nth-of-type(%d)", index)
+		}
+		parts = append(parts, part)
+	}
+
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return strings.Join(parts, " > ")
+}
+
+// CountIDs returns the number of elements in the document carrying each id
+func CountIDs(root *html.Node) map[string]int {
+	ids := make(map[string]int)
+	var countIDs func(*html.Node)
+	countIDs = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if id := GetAttr(n, "id"); id != "" {
+				ids[id]++
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			countIDs(c)
+		}
+	}
+	countIDs(root)
+	return ids
+}
+
+// escapes an identifier for use in a selector, as CSS.escape() does
+func cssEscape(ident string) string {
+	var b strings.Builder
+	runes := []rune(ident)
+	for i, r := range runes {
+		switch {
+		case r == 0:
+			b.WriteRune('\uFFFD')
+		case r < 0x20 || r == 0x7F,
+			r >= '0' && r <= '9' && (i == 0 || i == 1 && runes[0] == '-'):
+			fmt.Fprintf(&b, "\\%x ", r)
+		case r == '-' && i == 0 && len(runes) == 1:
+			b.WriteString("\\-")
+		case r >= 0x80, r == '-', r == '_', r >= '0' && r <= '9', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
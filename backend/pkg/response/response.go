@@ -10,20 +10,23 @@ import (
 type Response struct {
 	Status     string      `json:"status"`
 	StatusCode int         `json:"status_code,omitempty"`
+	Code       string      `json:"code,omitempty"`
 	Message    string      `json:"message,omitempty"`
 	Data       interface{} `json:"data,omitempty"`
 }
 
 func JSON(w http.ResponseWriter, statusCode int, data interface{}, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
-	res := Response{
+	write(w, Response{
 		Status:     http.StatusText(statusCode),
 		StatusCode: statusCode,
 		Message:    message,
 		Data:       data,
-	}
+	})
+}
+
+func write(w http.ResponseWriter, res Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(res.StatusCode)
 
 	err := json.NewEncoder(w).Encode(res)
 	if err != nil {
@@ -39,3 +42,13 @@ func Success(w http.ResponseWriter, data interface{}, message string) {
 func Error(w http.ResponseWriter, statusCode int, message string) {
 	JSON(w, statusCode, nil, message)
 }
+
+// ErrorWithCode is an error response with a machine readable code for errors that share a status code
+func ErrorWithCode(w http.ResponseWriter, statusCode int, code string, message string) {
+	write(w, Response{
+		Status:     http.StatusText(statusCode),
+		StatusCode: statusCode,
+		Code:       code,
+		Message:    message,
+	})
+}
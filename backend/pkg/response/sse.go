@@ -0,0 +1,29 @@
+package response
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// StartEventStream sets the headers of a Server-Sent Events response and sends them to the client
+func StartEventStream(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	return http.NewResponseController(w).Flush()
+}
+
+// Event writes a single Server-Sent Event with a JSON encoded payload and flushes it
+func Event(w http.ResponseWriter, id int, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, payload); err != nil {
+		return err
+	}
+	return http.NewResponseController(w).Flush()
+}